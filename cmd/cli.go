@@ -0,0 +1,371 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/diff"
+	"github.com/lastvoidtemplar/json_formatter/internal/lexer"
+	"github.com/lastvoidtemplar/json_formatter/internal/parser"
+	"github.com/lastvoidtemplar/json_formatter/internal/printer"
+	"github.com/lastvoidtemplar/json_formatter/internal/token"
+)
+
+const (
+	exitOK = 0
+	// the run completed, but some files are not formatted
+	exitUnformatted = 1
+	exitUsageErr    = 2
+	exitSyntaxErr   = 3
+	exitIOErr       = 4
+)
+
+type options struct {
+	indent       int
+	tabs         bool
+	compact      bool
+	width        int
+	sortKeys     string
+	keyPriority  string
+	sortTopLevel bool
+	json5        bool
+	strict       bool
+	allowCommas  bool
+	addCommas    bool
+	output       string
+	write        bool
+	list         bool
+	diff         bool
+	include      stringList
+	exclude      stringList
+	jobs         int
+	errorFormat  string
+	columns      string
+	mmap         bool
+	stream       bool
+	ndjson       bool
+	files        []string
+}
+
+func parseFlags(args []string, stderr io.Writer) (*options, error) {
+	opts := &options{}
+
+	fs := flag.NewFlagSet("json_formatter", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: json_formatter [flags] [path ...]")
+		fmt.Fprintln(stderr, "       json_formatter explain [code ...]")
+		fs.PrintDefaults()
+	}
+
+	fs.IntVar(&opts.indent, "indent", 4, "number of spaces per indentation level")
+	fs.BoolVar(&opts.tabs, "tabs", false, "indent with tabs instead of spaces")
+	fs.BoolVar(&opts.compact, "compact", false, "print without any whitespace, drops the comments")
+	fs.IntVar(&opts.width, "width", 0, "keep arrays and objects which fit in `columns` on a single line, 0 always expands them")
+	fs.StringVar(&opts.sortKeys, "sort-keys", "", "sort object keys: `order` is lex or natural")
+	fs.StringVar(&opts.keyPriority, "key-priority", "", "comma separated `keys` printed first in every object")
+	fs.BoolVar(&opts.sortTopLevel, "sort-top-level", false, "sort only the keys of the root object")
+	fs.BoolVar(&opts.json5, "json5", false, "parse the input as JSON5")
+	fs.BoolVar(&opts.strict, "strict", false, "convert the output to strict JSON, drops the comments")
+	fs.BoolVar(&opts.allowCommas, "allow-trailing-commas", false, "accept trailing commas in JSON input, they are dropped from the output")
+	fs.BoolVar(&opts.addCommas, "trailing-commas", false, "add trailing commas to expanded arrays and objects in JSON5 output")
+	fs.StringVar(&opts.output, "output", "", "write the result to `file` instead of stdout")
+	fs.StringVar(&opts.output, "o", "", "shorthand for -output")
+	fs.BoolVar(&opts.write, "write", false, "write the result to the source file instead of stdout")
+	fs.BoolVar(&opts.write, "w", false, "shorthand for -write")
+	fs.BoolVar(&opts.list, "check", false, "list files whose formatting differs and exit with 1 if there are any")
+	fs.BoolVar(&opts.list, "l", false, "shorthand for -check")
+	fs.BoolVar(&opts.diff, "diff", false, "print unified diffs between the files and their formatting")
+	fs.BoolVar(&opts.diff, "d", false, "shorthand for -diff")
+	fs.IntVar(&opts.jobs, "j", runtime.NumCPU(), "number of files formatted in parallel")
+	fs.Var(&opts.include, "include", "glob `pattern` of files to format inside directories (default *.json), can be repeated")
+	fs.StringVar(&opts.errorFormat, "error-format", "text", "print the errors as `format`: text, json lines or a sarif 2.1.0 log")
+	fs.StringVar(&opts.columns, "columns", "bytes", "count the columns of the errors in `unit`: bytes, runes or utf16")
+	fs.BoolVar(&opts.mmap, "mmap", false, "memory-map regular files instead of reading them and stream pipes, for huge documents")
+	fs.BoolVar(&opts.stream, "stream", false, "print the output while the input is read without keeping the document in memory, the output of an invalid file is cut at the error and duplicate keys are not reported")
+	fs.BoolVar(&opts.ndjson, "ndjson", false, "format every line as a separate document like in JSON Lines, use -compact to keep one record per line")
+	fs.Var(&opts.exclude, "exclude", "glob `pattern` of files and directories to skip inside directories, can be repeated")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if opts.jobs < 1 {
+		fmt.Fprintln(stderr, "-j must be at least 1")
+		return nil, errors.New("no workers")
+	}
+
+	if !slices.Contains(errorFormats, opts.errorFormat) {
+		fmt.Fprintf(stderr, "unknown -error-format %q\n", opts.errorFormat)
+		return nil, errors.New("unknown error format")
+	}
+
+	if _, ok := columnUnits[opts.columns]; !ok {
+		fmt.Fprintf(stderr, "unknown -columns unit %q\n", opts.columns)
+		return nil, errors.New("unknown column unit")
+	}
+
+	if opts.addCommas && (!opts.json5 || opts.strict) {
+		fmt.Fprintln(stderr, "-trailing-commas requires -json5 output")
+		return nil, errors.New("trailing commas in json")
+	}
+
+	if _, ok := keyOrders[opts.sortKeys]; !ok {
+		fmt.Fprintf(stderr, "unknown -sort-keys order %q\n", opts.sortKeys)
+		return nil, errors.New("unknown key order")
+	}
+
+	if opts.width < 0 {
+		fmt.Fprintln(stderr, "-width must not be negative")
+		return nil, errors.New("negative width")
+	}
+
+	if opts.indent < 0 {
+		fmt.Fprintln(stderr, "-indent must not be negative")
+		return nil, errors.New("negative indent")
+	}
+
+	if opts.stream && (opts.sortKeys != "" || opts.keyPriority != "" || opts.width > 0 && !opts.compact) {
+		fmt.Fprintln(stderr, "-stream can't be combined with -sort-keys, -key-priority and -width")
+		return nil, errors.New("stream with sorting")
+	}
+
+	if opts.stream && (opts.list || opts.diff || opts.write) {
+		fmt.Fprintln(stderr, "-stream can't be combined with -check, -diff and -write")
+		return nil, errors.New("stream with check")
+	}
+
+	opts.files = fs.Args()
+
+	if opts.write {
+		if opts.output != "" {
+			fmt.Fprintln(stderr, "-write can't be combined with -output")
+			return nil, errors.New("write with output")
+		}
+		if len(opts.files) == 0 || slices.Contains(opts.files, "-") {
+			fmt.Fprintln(stderr, "-write can't be used with stdin")
+			return nil, errors.New("write with stdin")
+		}
+	}
+
+	return opts, nil
+}
+
+var keyOrders = map[string]printer.KeyOrder{
+	"":        printer.KEEP_ORDER,
+	"lex":     printer.LEXICOGRAPHIC,
+	"natural": printer.NATURAL,
+}
+
+var columnUnits = map[string]token.ColumnUnit{
+	"bytes": token.BYTES,
+	"runes": token.CODE_POINTS,
+	"utf16": token.UTF16_CODE_UNITS,
+}
+
+func (opts *options) dialect() token.Dialect {
+	if opts.json5 {
+		return token.JSON5
+	}
+	return token.JSON
+}
+
+func (opts *options) lexerOptions() lexer.Options {
+	return lexer.Options{
+		Dialect: opts.dialect(),
+		Columns: columnUnits[opts.columns],
+	}
+}
+
+func (opts *options) parserOptions() parser.Options {
+	return parser.Options{
+		Dialect:             opts.dialect(),
+		AllowTrailingCommas: opts.allowCommas,
+		Recover:             true,
+	}
+}
+
+func (opts *options) printerConfig() printer.Config {
+	cfg := printer.Config{
+		Newline:          "\n",
+		SortKeys:         keyOrders[opts.sortKeys],
+		SortTopLevelOnly: opts.sortTopLevel,
+		StrictJSON:       opts.strict,
+		TrailingCommas:   opts.addCommas,
+	}
+
+	if opts.keyPriority != "" {
+		cfg.KeyPriority = strings.Split(opts.keyPriority, ",")
+	}
+
+	if opts.compact {
+		cfg.Compact = true
+		return cfg
+	}
+
+	cfg.Indent = printer.NewIndent(opts.indent, opts.tabs)
+	cfg.MaxWidth = opts.width
+	return cfg
+}
+
+type app struct {
+	opts  *options
+	cfg   printer.Config
+	stdin io.Reader
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) > 0 && args[0] == "explain" {
+		return explain(args[1:], stdout, stderr)
+	}
+
+	opts, err := parseFlags(args, stderr)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsageErr
+	}
+
+	log := &errorLog{
+		format: opts.errorFormat,
+		w:      stderr,
+	}
+	defer log.flush()
+
+	// the output file replaces the old one only after all inputs are formatted,
+	// so it can be one of the inputs and an error doesn't leave half of it
+	var output *atomicFile
+	out := stdout
+	if opts.output != "" {
+		output, err = createAtomic(opts.output)
+		if err != nil {
+			log.report(opts.output, nil, err)
+			return exitIOErr
+		}
+		out = output
+	}
+
+	a := &app{
+		opts:  opts,
+		cfg:   opts.printerConfig(),
+		stdin: stdin,
+	}
+
+	paths := opts.files
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+
+	files := expandPaths(paths, opts.include, opts.exclude)
+	var code int
+	if opts.stream {
+		code = a.streamAll(files, out, log)
+	} else {
+		code = a.processAll(files, out, log)
+	}
+
+	if output == nil {
+		return code
+	}
+
+	if code > exitUnformatted {
+		output.abort()
+		return code
+	}
+
+	if err := output.commit(); err != nil {
+		log.report(opts.output, nil, err)
+		return exitIOErr
+	}
+	return code
+}
+
+// formats a single file ("-" is stdin) and depending on the mode prints it,
+// lists it, diffs it or rewrites it. files which are already formatted are not rewritten
+func (a *app) processPath(name string, out io.Writer, log *errorLog) int {
+	in, err := a.open(name)
+	if err != nil {
+		log.report(displayName(name), nil, err)
+		return exitIOErr
+	}
+	defer in.close()
+
+	var res []byte
+	if a.opts.ndjson {
+		var buf bytes.Buffer
+		invalid := false
+		err = formatRecords(in.r, &buf, a.cfg, a.opts.lexerOptions(), a.opts.parserOptions(), func(record []byte, err error) {
+			log.report(displayName(name), record, err)
+			invalid = true
+		})
+		if err == nil && invalid {
+			return exitSyntaxErr
+		}
+		res = buf.Bytes()
+	} else {
+		res, err = format(in.lex, a.cfg, a.opts.parserOptions())
+	}
+	if err != nil {
+		log.report(displayName(name), in.src, err)
+		return exitCode(err)
+	}
+	src := in.src
+
+	if !a.opts.list && !a.opts.write && !a.opts.diff {
+		if _, err := out.Write(res); err != nil {
+			log.report(displayName(name), nil, err)
+			return exitIOErr
+		}
+		return exitOK
+	}
+
+	if bytes.Equal(src, res) {
+		return exitOK
+	}
+
+	if a.opts.list {
+		fmt.Fprintln(out, displayName(name))
+	}
+
+	if a.opts.diff {
+		name := displayName(name)
+		if _, err := out.Write(diff.Unified(name+".orig", name, src, res)); err != nil {
+			log.report(displayName(name), nil, err)
+			return exitIOErr
+		}
+	}
+
+	if a.opts.write {
+		if err := writeFileAtomic(name, res); err != nil {
+			log.report(displayName(name), nil, err)
+			return exitIOErr
+		}
+	}
+
+	if a.opts.list || a.opts.diff {
+		return exitUnformatted
+	}
+	return exitOK
+}
+
+func displayName(name string) string {
+	if name == "-" {
+		return "<stdin>"
+	}
+	return name
+}
+
+func exitCode(err error) int {
+	var parserErr *parser.ParserError
+	if errors.As(err, &parserErr) || errors.Is(err, parser.ErrEmptyLexer) {
+		return exitSyntaxErr
+	}
+	return exitIOErr
+}
@@ -0,0 +1,922 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func writeFile(t *testing.T, dir string, name string, content string) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunStdin(t *testing.T) {
+	var stdout, stderr strings.Builder
+	code := run([]string{"-indent", "2"}, strings.NewReader(`{"a":[1,true]}`), &stdout, &stderr)
+
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, but got %d (%s)", exitOK, code, stderr.String())
+	}
+
+	expected := "{\n  \"a\": [\n    1,\n    true\n  ]\n}\n"
+	if stdout.String() != expected {
+		t.Fatalf("Expected %q, but got %q", expected, stdout.String())
+	}
+}
+
+func TestRunMultipleFilesToOutput(t *testing.T) {
+	dir := t.TempDir()
+	first := writeFile(t, dir, "first.json", `[1]`)
+	second := writeFile(t, dir, "second.json", `{}`)
+	output := filepath.Join(dir, "out.json")
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"--tabs", "--output", output, first, second}, nil, &stdout, &stderr)
+
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, but got %d (%s)", exitOK, code, stderr.String())
+	}
+
+	if stdout.Len() != 0 {
+		t.Fatalf("Expected nothing on stdout, but got %q", stdout.String())
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "[\n\t1\n]\n{}\n"
+	if string(content) != expected {
+		t.Fatalf("Expected %q, but got %q", expected, string(content))
+	}
+}
+
+func TestRunOutputIsInput(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "same.json", `{"a":1}`)
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"-o", path, path}, nil, &stdout, &stderr)
+
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, but got %d (%s)", exitOK, code, stderr.String())
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "{\n    \"a\": 1\n}\n"
+	if string(content) != expected {
+		t.Fatalf("Expected %q, but got %q", expected, string(content))
+	}
+}
+
+func TestRunOutputSyntaxErrKeepsFile(t *testing.T) {
+	dir := t.TempDir()
+	valid := writeFile(t, dir, "valid.json", `[1]`)
+	invalid := writeFile(t, dir, "invalid.json", `[1`)
+	output := writeFile(t, dir, "out.json", "old\n")
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"-o", output, valid, invalid}, nil, &stdout, &stderr)
+
+	if code != exitSyntaxErr {
+		t.Fatalf("Expected exit code %d, but got %d", exitSyntaxErr, code)
+	}
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != "old\n" {
+		t.Fatalf("Expected the output file to be kept, but got %q", string(content))
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 3 {
+		t.Fatalf("Expected the temp file to be removed, but found %d entries", len(entries))
+	}
+}
+
+func TestRunSyntaxErr(t *testing.T) {
+	var stdout, stderr strings.Builder
+	code := run(nil, strings.NewReader("[1,\n 2"), &stdout, &stderr)
+
+	if code != exitSyntaxErr {
+		t.Fatalf("Expected exit code %d, but got %d", exitSyntaxErr, code)
+	}
+
+	if !strings.HasPrefix(stderr.String(), "<stdin>:2:3: ") {
+		t.Fatalf("Expected error with position, but got %q", stderr.String())
+	}
+}
+
+func TestRunSyntaxErrAll(t *testing.T) {
+	var stdout, stderr strings.Builder
+	code := run(nil, strings.NewReader("[1 2,\n ture,\n 3"), &stdout, &stderr)
+
+	if code != exitSyntaxErr {
+		t.Fatalf("Expected exit code %d, but got %d", exitSyntaxErr, code)
+	}
+
+	lines := []string{}
+	for _, line := range strings.Split(stderr.String(), "\n") {
+		if strings.HasPrefix(line, "<stdin>:") {
+			lines = append(lines, line)
+		}
+	}
+
+	prefixes := []string{"<stdin>:1:4: ", "<stdin>:2:2: ", "<stdin>:3:3: "}
+	if len(lines) != len(prefixes) {
+		t.Fatalf("Expected %d errors, but got %q", len(prefixes), stderr.String())
+	}
+
+	for i, line := range lines {
+		if !strings.HasPrefix(line, prefixes[i]) {
+			t.Errorf("Expected error starting with %q, but got %q", prefixes[i], line)
+		}
+	}
+
+	if stdout.Len() != 0 {
+		t.Errorf("Expected no output, but got %q", stdout.String())
+	}
+}
+
+// the io error wins over the syntax error of the other file
+func TestRunIOErrWithSyntaxErr(t *testing.T) {
+	dir := t.TempDir()
+	invalid := writeFile(t, dir, "invalid.json", `[1`)
+
+	var stdout, stderr strings.Builder
+	code := run([]string{invalid, filepath.Join(dir, "missing.json")}, nil, &stdout, &stderr)
+
+	if code != exitIOErr {
+		t.Fatalf("Expected exit code %d, but got %d", exitIOErr, code)
+	}
+}
+
+func TestRunCheckFormattedComments(t *testing.T) {
+	var formatted, stderr strings.Builder
+	code := run(nil, strings.NewReader(`{/*a*/"a": [/*0*/0, /*b*/ /*c*/ 1], "e": [/*only*/]}`), &formatted, &stderr)
+
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, but got %d (%s)", exitOK, code, stderr.String())
+	}
+
+	var stdout strings.Builder
+	code = run([]string{"-check"}, strings.NewReader(formatted.String()), &stdout, &stderr)
+
+	if code != exitOK {
+		t.Fatalf("Expected the formatted output to pass -check, but got exit code %d for:\n%s", code, formatted.String())
+	}
+}
+
+func TestRunUsageErr(t *testing.T) {
+	var stdout, stderr strings.Builder
+	code := run([]string{"-indent", "-1"}, nil, &stdout, &stderr)
+
+	if code != exitUsageErr {
+		t.Fatalf("Expected exit code %d, but got %d", exitUsageErr, code)
+	}
+}
+
+func TestRunWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "data.json", `{"a":1}`)
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"-w", path}, nil, &stdout, &stderr)
+
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, but got %d (%s)", exitOK, code, stderr.String())
+	}
+
+	if stdout.Len() != 0 {
+		t.Fatalf("Expected nothing on stdout, but got %q", stdout.String())
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "{\n    \"a\": 1\n}\n"
+	if string(content) != expected {
+		t.Fatalf("Expected %q, but got %q", expected, string(content))
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("Expected permissions %o, but got %o", 0600, info.Mode().Perm())
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("Expected temp files to be cleaned up, but found %d entries", len(entries))
+	}
+}
+
+func TestRunWriteSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := writeFile(t, dir, "target.json", `{"a":1}`)
+	link := filepath.Join(dir, "link.json")
+	if err := os.Symlink("target.json", link); err != nil {
+		t.Skip(err)
+	}
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"-w", link}, nil, &stdout, &stderr)
+
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, but got %d (%s)", exitOK, code, stderr.String())
+	}
+
+	info, err := os.Lstat(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("Expected %s to stay a symlink, but got mode %v", link, info.Mode())
+	}
+
+	content, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "{\n    \"a\": 1\n}\n"
+	if string(content) != expected {
+		t.Fatalf("Expected %q in the target, but got %q", expected, string(content))
+	}
+}
+
+func TestRunWriteSyntaxErrKeepsFile(t *testing.T) {
+	dir := t.TempDir()
+	input := `{"a": [1, 2}`
+	path := writeFile(t, dir, "data.json", input)
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"--write", path}, nil, &stdout, &stderr)
+
+	if code != exitSyntaxErr {
+		t.Fatalf("Expected exit code %d, but got %d", exitSyntaxErr, code)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != input {
+		t.Fatalf("Expected file to be untouched, but got %q", string(content))
+	}
+}
+
+func TestRunWriteSkipsFormatted(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "data.json", "[\n    1\n]\n")
+
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"-w", path}, nil, &stdout, &stderr)
+
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, but got %d (%s)", exitOK, code, stderr.String())
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !info.ModTime().Equal(past) {
+		t.Fatal("Expected formatted file to not be rewritten")
+	}
+}
+
+func TestRunWriteStdin(t *testing.T) {
+	var stdout, stderr strings.Builder
+	code := run([]string{"-w"}, strings.NewReader("[]"), &stdout, &stderr)
+
+	if code != exitUsageErr {
+		t.Fatalf("Expected exit code %d, but got %d", exitUsageErr, code)
+	}
+}
+
+func TestRunCheck(t *testing.T) {
+	dir := t.TempDir()
+	formatted := writeFile(t, dir, "formatted.json", "[\n    1\n]\n")
+	unformatted := writeFile(t, dir, "unformatted.json", `[1]`)
+	invalid := writeFile(t, dir, "invalid.json", "{\n\t\"a\" 1\n}")
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"--check", formatted, unformatted}, nil, &stdout, &stderr)
+
+	if code != exitUnformatted {
+		t.Fatalf("Expected exit code %d, but got %d (%s)", exitUnformatted, code, stderr.String())
+	}
+
+	if stdout.String() != unformatted+"\n" {
+		t.Fatalf("Expected only %s to be listed, but got %q", unformatted, stdout.String())
+	}
+
+	content, err := os.ReadFile(unformatted)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != `[1]` {
+		t.Fatalf("Expected file to be untouched, but got %q", string(content))
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	code = run([]string{"-l", invalid, unformatted}, nil, &stdout, &stderr)
+
+	if code != exitSyntaxErr {
+		t.Fatalf("Expected exit code %d, but got %d", exitSyntaxErr, code)
+	}
+
+	if stdout.String() != unformatted+"\n" {
+		t.Fatalf("Expected %s to be listed, but got %q", unformatted, stdout.String())
+	}
+
+	if !strings.HasPrefix(stderr.String(), invalid+":2:6: ") {
+		t.Fatalf("Expected error with file and position, but got %q", stderr.String())
+	}
+}
+
+func TestRunDiff(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "data.json", "[\n  1,\n    2\n]\n")
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"-d", path}, nil, &stdout, &stderr)
+
+	if code != exitUnformatted {
+		t.Fatalf("Expected exit code %d, but got %d (%s)", exitUnformatted, code, stderr.String())
+	}
+
+	expected := "--- " + path + ".orig\n" +
+		"+++ " + path + "\n" +
+		"@@ -1,4 +1,4 @@\n" +
+		" [\n" +
+		"-  1,\n" +
+		"+    1,\n" +
+		"     2\n" +
+		" ]\n"
+
+	if stdout.String() != expected {
+		t.Fatalf("Expected:\n%s\nbut got:\n%s", expected, stdout.String())
+	}
+}
+
+func TestRunDirectories(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"a/b", ".git", "node_modules/pkg", "vendor", "fixtures/skip"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeFile(t, dir, "root.json", `[1]`)
+	writeFile(t, dir, "notes.txt", `[2]`)
+	writeFile(t, dir, "a/b/nested.json", `[3]`)
+	writeFile(t, dir, "a/b/nested.jsonc", `[4]`)
+	writeFile(t, dir, ".git/config.json", `[5]`)
+	writeFile(t, dir, "node_modules/pkg/package.json", `[6]`)
+	writeFile(t, dir, "vendor/vendored.json", `[7]`)
+	writeFile(t, dir, "fixtures/skip/skipped.json", `[8]`)
+	writeFile(t, dir, "fixtures/generated.json", `[9]`)
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"-l", "--exclude", "skip", "--exclude", "*generated*", dir}, nil, &stdout, &stderr)
+
+	if code != exitUnformatted {
+		t.Fatalf("Expected exit code %d, but got %d (%s)", exitUnformatted, code, stderr.String())
+	}
+
+	expected := filepath.Join(dir, "a/b/nested.json") + "\n" +
+		filepath.Join(dir, "root.json") + "\n"
+
+	if stdout.String() != expected {
+		t.Fatalf("Expected:\n%s\nbut got:\n%s", expected, stdout.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	code = run([]string{"-l", "--include", "*.jsonc", "--include", "b/*.json", filepath.Join(dir, "a"), filepath.Join(dir, "notes.txt")}, nil, &stdout, &stderr)
+
+	if code != exitUnformatted {
+		t.Fatalf("Expected exit code %d, but got %d (%s)", exitUnformatted, code, stderr.String())
+	}
+
+	expected = filepath.Join(dir, "a/b/nested.json") + "\n" +
+		filepath.Join(dir, "a/b/nested.jsonc") + "\n" +
+		filepath.Join(dir, "notes.txt") + "\n"
+
+	if stdout.String() != expected {
+		t.Fatalf("Expected:\n%s\nbut got:\n%s", expected, stdout.String())
+	}
+}
+
+func TestRunParallelKeepsOrder(t *testing.T) {
+	dir := t.TempDir()
+
+	args := []string{"-j", "4", "-compact"}
+	var expected strings.Builder
+	for i := range 50 {
+		name := fmt.Sprintf("%02d.json", i)
+		if i%7 == 3 {
+			args = append(args, writeFile(t, dir, name, fmt.Sprintf("[%d", i)))
+			continue
+		}
+		args = append(args, writeFile(t, dir, name, fmt.Sprintf("[%d]", i)))
+		expected.WriteString(fmt.Sprintf("[%d]\n", i))
+	}
+
+	var stdout, stderr strings.Builder
+	code := run(args, nil, &stdout, &stderr)
+
+	if code != exitSyntaxErr {
+		t.Fatalf("Expected exit code %d, but got %d", exitSyntaxErr, code)
+	}
+
+	if stdout.String() != expected.String() {
+		t.Fatalf("Expected %q, but got %q", expected.String(), stdout.String())
+	}
+
+	// the source snippets of the diagnostics are skipped
+	lines := []string{}
+	for _, line := range strings.Split(stderr.String(), "\n") {
+		if strings.HasPrefix(line, dir) {
+			lines = append(lines, line)
+		}
+	}
+
+	if len(lines) != 7 {
+		t.Fatalf("Expected 7 errors, but got %d", len(lines))
+	}
+
+	for i, line := range lines {
+		prefix := filepath.Join(dir, fmt.Sprintf("%02d.json", i*7+3))
+		if !strings.HasPrefix(line, prefix) {
+			t.Fatalf("Expected error[%d] for %s, but got %s", i, prefix, line)
+		}
+	}
+}
+
+func TestRunAllowTrailingCommas(t *testing.T) {
+	var stdout, stderr strings.Builder
+	code := run([]string{"-compact"}, strings.NewReader(`{"a": [1, 2,],}`), &stdout, &stderr)
+
+	if code != exitSyntaxErr {
+		t.Fatalf("Expected exit code %d, but got %d", exitSyntaxErr, code)
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	code = run([]string{"-compact", "-allow-trailing-commas"}, strings.NewReader(`{"a": [1, 2,],}`), &stdout, &stderr)
+
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, but got %d (%s)", exitOK, code, stderr.String())
+	}
+
+	if stdout.String() != "{\"a\":[1,2]}\n" {
+		t.Fatalf("Expected trailing commas to be dropped, but got %q", stdout.String())
+	}
+}
+
+func TestRunErrorFormatJSON(t *testing.T) {
+	dir := t.TempDir()
+	bad := writeFile(t, dir, "bad.json", "[1 2]")
+	missing := filepath.Join(dir, "missing.json")
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"-error-format", "json", bad, missing}, nil, &stdout, &stderr)
+
+	if code != exitIOErr {
+		t.Fatalf("Expected exit code %d, but got %d", exitIOErr, code)
+	}
+
+	lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 json errors, but got %q", stderr.String())
+	}
+
+	var errs [2]struct {
+		File string `json:"file"`
+		Row  int    `json:"row"`
+		Colm int    `json:"colm"`
+		Code string `json:"code"`
+	}
+
+	for i, line := range lines {
+		if err := json.Unmarshal([]byte(line), &errs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if errs[0].File != bad || errs[0].Row != 1 || errs[0].Colm != 4 || errs[0].Code != "JF1004" {
+		t.Errorf("Expected JF1004 in %s at 1:4 first, but got %+v", bad, errs[0])
+	}
+
+	if errs[1].File != missing || errs[1].Code != "JF2001" {
+		t.Errorf("Expected JF2001 in %s, but got %+v", missing, errs[1])
+	}
+}
+
+func TestRunWalkErrorsInOrder(t *testing.T) {
+	dir := t.TempDir()
+	first := writeFile(t, dir, "a.json", `[1]`)
+	missing := filepath.Join(dir, "missing")
+	last := writeFile(t, dir, "b.json", `[2]`)
+
+	for _, mode := range []string{"-j=4", "-stream"} {
+		// the same builder shows the order of stdout and stderr
+		var out strings.Builder
+		code := run([]string{mode, "-compact", first, missing, last}, nil, &out, &out)
+
+		if code != exitIOErr {
+			t.Fatalf("Expected exit code %d with %s, but got %d", exitIOErr, mode, code)
+		}
+
+		lines := strings.Split(out.String(), "\n")
+		if len(lines) != 4 || lines[0] != "[1]" || !strings.Contains(lines[1], missing) || lines[2] != "[2]" {
+			t.Errorf("Expected the error between the files with %s, but got %q", mode, out.String())
+		}
+	}
+}
+
+func TestRunErrorFormatSARIF(t *testing.T) {
+	dir := t.TempDir()
+	first := writeFile(t, dir, "a.json", "[nul]")
+	second := writeFile(t, dir, "b.json", "{\"a\" 1}")
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"-error-format", "sarif", "-j", "2", first, second}, nil, &stdout, &stderr)
+
+	if code != exitSyntaxErr {
+		t.Fatalf("Expected exit code %d, but got %d", exitSyntaxErr, code)
+	}
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				RuleID string `json:"ruleId"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+
+	if err := json.Unmarshal([]byte(stderr.String()), &log); err != nil {
+		t.Fatalf("Expected a single sarif log, but got %q: %v", stderr.String(), err)
+	}
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Results) != 2 {
+		t.Fatalf("Expected a run with 2 results, but got %+v", log)
+	}
+
+	if log.Runs[0].Results[0].RuleID != "JF1002" || log.Runs[0].Results[1].RuleID != "JF1009" {
+		t.Errorf("Expected the results in the order of the files, but got %+v", log.Runs[0].Results)
+	}
+}
+
+func TestRunErrorFormatUnknown(t *testing.T) {
+	var stdout, stderr strings.Builder
+	code := run([]string{"-error-format", "xml"}, nil, &stdout, &stderr)
+
+	if code != exitUsageErr {
+		t.Fatalf("Expected exit code %d, but got %d", exitUsageErr, code)
+	}
+}
+
+func TestRunExplain(t *testing.T) {
+	var stdout, stderr strings.Builder
+	code := run([]string{"explain", "JF1004"}, nil, &stdout, &stderr)
+
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, but got %d", exitOK, code)
+	}
+
+	if !strings.HasPrefix(stdout.String(), "JF1004 missing-array-separator\n") {
+		t.Fatalf("Expected the explanation of JF1004, but got %q", stdout.String())
+	}
+
+	stdout.Reset()
+	code = run([]string{"explain", "JF0000"}, nil, &stdout, &stderr)
+
+	if code != exitUsageErr {
+		t.Fatalf("Expected exit code %d, but got %d", exitUsageErr, code)
+	}
+}
+
+func TestRunSyntaxErrCode(t *testing.T) {
+	var stdout, stderr strings.Builder
+	run(nil, strings.NewReader(`{"a" 1}`), &stdout, &stderr)
+
+	first, _, _ := strings.Cut(stderr.String(), "\n")
+	if !strings.HasPrefix(first, "<stdin>:1:6: ") || !strings.HasSuffix(first, " [JF1009]") {
+		t.Fatalf("Expected error with code, but got %q", stderr.String())
+	}
+}
+
+func TestRunColumns(t *testing.T) {
+	var stdout, stderr strings.Builder
+	run([]string{"-columns", "runes"}, strings.NewReader(`["ä" 1]`), &stdout, &stderr)
+
+	if !strings.HasPrefix(stderr.String(), "<stdin>:1:6: ") {
+		t.Fatalf("Expected the column in code points, but got %q", stderr.String())
+	}
+
+	code := run([]string{"-columns", "chars"}, nil, &stdout, &stderr)
+
+	if code != exitUsageErr {
+		t.Fatalf("Expected exit code %d, but got %d", exitUsageErr, code)
+	}
+}
+
+func TestRunMmap(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "a.json", `{"a":[1,"b"]}`)
+	invalid := writeFile(t, dir, "b.json", `{"a": ture}`)
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"-mmap", "-indent", "2", path}, nil, &stdout, &stderr)
+
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, but got %d (%s)", exitOK, code, stderr.String())
+	}
+
+	expected := "{\n  \"a\": [\n    1,\n    \"b\"\n  ]\n}\n"
+	if stdout.String() != expected {
+		t.Fatalf("Expected %q, but got %q", expected, stdout.String())
+	}
+
+	stdout.Reset()
+	code = run([]string{"-mmap", "-check", path}, nil, &stdout, &stderr)
+
+	if code != exitUnformatted || stdout.String() != path+"\n" {
+		t.Fatalf("Expected exit code %d and %s listed, but got %d and %q", exitUnformatted, path, code, stdout.String())
+	}
+
+	code = run([]string{"-mmap", invalid}, nil, &stdout, &stderr)
+
+	if code != exitSyntaxErr || !strings.Contains(stderr.String(), " 1 | {\"a\": ture}") {
+		t.Fatalf("Expected a syntax error with the source line, but got %d and %q", code, stderr.String())
+	}
+}
+
+func TestRunMmapStreamsStdin(t *testing.T) {
+	var stdout, stderr strings.Builder
+	code := run([]string{"-mmap", "-compact"}, strings.NewReader(`[1, {"a": null}]`), &stdout, &stderr)
+
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, but got %d (%s)", exitOK, code, stderr.String())
+	}
+
+	expected := "[1,{\"a\":null}]\n"
+	if stdout.String() != expected {
+		t.Fatalf("Expected %q, but got %q", expected, stdout.String())
+	}
+
+	code = run([]string{"-mmap"}, strings.NewReader(`[1 2]`), &stdout, &stderr)
+
+	if code != exitSyntaxErr {
+		t.Fatalf("Expected exit code %d, but got %d (%s)", exitSyntaxErr, code, stderr.String())
+	}
+}
+
+func TestRunStream(t *testing.T) {
+	input := "// head\n{\"a\": [1, /* c */ 2], \"b\": {}} // tail\n"
+
+	var expected, stderr strings.Builder
+	run([]string{"-indent", "2"}, strings.NewReader(input), &expected, &stderr)
+
+	var stdout strings.Builder
+	code := run([]string{"-stream", "-indent", "2"}, strings.NewReader(input), &stdout, &stderr)
+
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, but got %d (%s)", exitOK, code, stderr.String())
+	}
+
+	if stdout.String() != expected.String() {
+		t.Fatalf("Expected %q, but got %q", expected.String(), stdout.String())
+	}
+}
+
+func TestRunStreamSyntaxErr(t *testing.T) {
+	dir := t.TempDir()
+	invalid := writeFile(t, dir, "a.json", `[1, ture]`)
+	valid := writeFile(t, dir, "b.json", `[2]`)
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"-stream", "-compact", invalid, valid}, nil, &stdout, &stderr)
+
+	if code != exitSyntaxErr {
+		t.Fatalf("Expected exit code %d, but got %d", exitSyntaxErr, code)
+	}
+
+	if stdout.String() != "[1\n[2]\n" {
+		t.Fatalf("Expected the output to be cut at the error, but got %q", stdout.String())
+	}
+
+	if !strings.HasPrefix(stderr.String(), invalid+":1:5: ") {
+		t.Fatalf("Expected the error with the position, but got %q", stderr.String())
+	}
+}
+
+func TestRunStreamDuplicateKeys(t *testing.T) {
+	var stdout, stderr strings.Builder
+	code := run([]string{"-stream", "-compact"}, strings.NewReader(`{"a": 1, "a": 2}`), &stdout, &stderr)
+
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, but got %d (%s)", exitOK, code, stderr.String())
+	}
+
+	if stdout.String() != "{\"a\":1,\"a\":2}\n" {
+		t.Fatalf("Expected the duplicate keys to be kept, but got %q", stdout.String())
+	}
+}
+
+func TestRunStreamReadErr(t *testing.T) {
+	stdin := io.MultiReader(strings.NewReader("[1, 2"), iotest.ErrReader(errors.New("input/output error")))
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"-stream", "-compact", "-error-format", "json"}, stdin, &stdout, &stderr)
+
+	if code != exitIOErr {
+		t.Fatalf("Expected exit code %d, but got %d (%s)", exitIOErr, code, stderr.String())
+	}
+
+	if !strings.Contains(stderr.String(), `"code":"JF2001"`) {
+		t.Fatalf("Expected an io-error, but got %q", stderr.String())
+	}
+}
+
+func TestRunStreamUnsupported(t *testing.T) {
+	args := [][]string{
+		{"-stream", "-sort-keys", "lex"},
+		{"-stream", "-width", "80"},
+		{"-stream", "-check"},
+	}
+
+	for _, arg := range args {
+		var stdout, stderr strings.Builder
+		code := run(arg, strings.NewReader(`[]`), &stdout, &stderr)
+
+		if code != exitUsageErr {
+			t.Errorf("Expected exit code %d for %v, but got %d", exitUsageErr, arg, code)
+		}
+	}
+}
+
+func TestRunNDJSON(t *testing.T) {
+	input := "{\"id\":1,\"tags\":[\"a\"]}\n\n{\"id\": 2}\r\n// no record\n[]\n"
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"-ndjson", "-compact"}, strings.NewReader(input), &stdout, &stderr)
+
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, but got %d (%s)", exitOK, code, stderr.String())
+	}
+
+	expected := "{\"id\":1,\"tags\":[\"a\"]}\n{\"id\":2}\n[]\n"
+	if stdout.String() != expected {
+		t.Fatalf("Expected %q, but got %q", expected, stdout.String())
+	}
+
+	stdout.Reset()
+	code = run([]string{"-ndjson", "-indent", "2"}, strings.NewReader(`{"id":1}`+"\n"+`[2]`), &stdout, &stderr)
+
+	expected = "{\n  \"id\": 1\n}\n[\n  2\n]\n"
+	if code != exitOK || stdout.String() != expected {
+		t.Fatalf("Expected %q, but got %d and %q", expected, code, stdout.String())
+	}
+}
+
+func TestRunNDJSONErrors(t *testing.T) {
+	input := "{\"id\": 1}\n{\"id\": ture}\n{\"id\": 3}\n[1 2]\n"
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"-ndjson"}, strings.NewReader(input), &stdout, &stderr)
+
+	if code != exitSyntaxErr {
+		t.Fatalf("Expected exit code %d, but got %d", exitSyntaxErr, code)
+	}
+
+	if stdout.Len() != 0 {
+		t.Fatalf("Expected no output for invalid input, but got %q", stdout.String())
+	}
+
+	for _, expected := range []string{
+		"<stdin>:2:8: ",
+		" 2 | {\"id\": ture}",
+		"<stdin>:4:4: ",
+		" 4 | [1 2]",
+	} {
+		if !strings.Contains(stderr.String(), expected) {
+			t.Errorf("Expected %q in the errors, but got:\n%s", expected, stderr.String())
+		}
+	}
+
+	// with -stream the valid records are still written
+	stdout.Reset()
+	code = run([]string{"-ndjson", "-stream", "-compact"}, strings.NewReader(input), &stdout, &stderr)
+
+	if code != exitSyntaxErr || stdout.String() != "{\"id\":1}\n{\"id\":3}\n" {
+		t.Fatalf("Expected the valid records, but got %d and %q", code, stdout.String())
+	}
+}
+
+func TestRunNDJSONCheck(t *testing.T) {
+	dir := t.TempDir()
+	formatted := writeFile(t, dir, "a.jsonl", "{\"id\":1}\n{\"id\":2}\n")
+	unformatted := writeFile(t, dir, "b.jsonl", "{\"id\": 1}\n")
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"-ndjson", "-compact", "-check", formatted, unformatted}, nil, &stdout, &stderr)
+
+	if code != exitUnformatted || stdout.String() != unformatted+"\n" {
+		t.Fatalf("Expected only %s listed, but got %d and %q", unformatted, code, stdout.String())
+	}
+}
+
+func TestRunNDJSONUnclosedRecord(t *testing.T) {
+	input := "{\"a\":1}\n\n[1,2\n{\"é\": tru}\n"
+
+	var stdout, stderr strings.Builder
+	code := run([]string{"-ndjson"}, strings.NewReader(input), &stdout, &stderr)
+
+	if code != exitSyntaxErr {
+		t.Fatalf("Expected exit code %d, but got %d", exitSyntaxErr, code)
+	}
+
+	for _, expected := range []string{
+		"<stdin>:3:5: expected ']', but got EOF",
+		" 3 | [1,2\n",
+		"<stdin>:4:",
+		" 4 | {\"é\": tru}\n",
+	} {
+		if !strings.Contains(stderr.String(), expected) {
+			t.Errorf("Expected %q in the errors, but got:\n%s", expected, stderr.String())
+		}
+	}
+}
+
+func TestRunNDJSONStreamErrorsInOrder(t *testing.T) {
+	input := "{\"id\": 1}\n{\"id\": ture}\n{\"id\": 3}\n"
+
+	// stdout and stderr in one writer show the order of the output and the errors
+	var combined strings.Builder
+	code := run([]string{"-ndjson", "-stream", "-compact"}, strings.NewReader(input), &combined, &combined)
+
+	if code != exitSyntaxErr {
+		t.Fatalf("Expected exit code %d, but got %d", exitSyntaxErr, code)
+	}
+
+	expected := "{\"id\":1}\n" +
+		"<stdin>:2:8: expected STRING, NUMBER, TRUE, FALSE, NULL, '[' or '{', but got ture [JF1002]\n" +
+		" 2 | {\"id\": ture}\n" +
+		"   |        ^~~~\n" +
+		"   = hint: did you mean `true`?\n" +
+		"{\"id\":3}\n"
+	if combined.String() != expected {
+		t.Fatalf("Expected:\n%s\nbut got:\n%s", expected, combined.String())
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/diagnostic"
+)
+
+var errorFormats = []string{"text", "json", "sarif"}
+
+// reports the errors in the -error-format. text and json are written right away,
+// sarif keeps them until the whole log is written by flush
+type errorLog struct {
+	format string
+	w      io.Writer
+	errs   []diagnostic.Error
+}
+
+// src is used only for the syntax errors and can be nil
+func (log *errorLog) report(name string, src []byte, err error) {
+	switch log.format {
+	case "json":
+		diagnostic.WriteJSON(log.w, diagnostic.NewErrors(name, src, err))
+	case "sarif":
+		log.errs = append(log.errs, diagnostic.NewErrors(name, src, err)...)
+	default:
+		// the syntax errors of streamed input have no source, but still need the name
+		if src == nil && exitCode(err) != exitSyntaxErr {
+			fmt.Fprintln(log.w, err)
+			return
+		}
+		diagnostic.New(log.w, name, src).Render(err)
+	}
+}
+
+// the log of a single file, which writes into w
+func (log *errorLog) fork(w io.Writer) *errorLog {
+	return &errorLog{
+		format: log.format,
+		w:      w,
+	}
+}
+
+// takes the errors of the forked log after its output is flushed
+func (log *errorLog) merge(other *errorLog) {
+	log.errs = append(log.errs, other.errs...)
+}
+
+func (log *errorLog) flush() {
+	if log.format == "sarif" {
+		diagnostic.WriteSARIF(log.w, log.errs)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/diagnostic"
+)
+
+// prints the long description of the error codes, without codes lists all of them
+func explain(codes []string, stdout io.Writer, stderr io.Writer) int {
+	if len(codes) == 0 {
+		for _, rule := range diagnostic.Rules {
+			fmt.Fprintf(stdout, "%s  %-32s %s\n", rule.Code, rule.Name, rule.Description)
+		}
+		return exitOK
+	}
+
+	code := exitOK
+	for i, name := range codes {
+		rule, ok := diagnostic.Lookup(name)
+		if !ok {
+			fmt.Fprintf(stderr, "unknown error code %q\n", name)
+			code = exitUsageErr
+			continue
+		}
+
+		if i > 0 {
+			fmt.Fprintln(stdout)
+		}
+		if err := diagnostic.Explain(stdout, rule); err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitIOErr
+		}
+	}
+
+	return code
+}
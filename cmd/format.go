@@ -0,0 +1,30 @@
+package main
+
+import (
+	"bytes"
+	"iter"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/parser"
+	"github.com/lastvoidtemplar/json_formatter/internal/printer"
+	"github.com/lastvoidtemplar/json_formatter/internal/token"
+)
+
+// runs the parser -> printer part of the pipeline, the output is kept in memory
+func format(lex iter.Seq[token.Token], cfg printer.Config, opts parser.Options) ([]byte, error) {
+	p, err := parser.NewWithOptions(lex, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := p.Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := printer.New(&buf, cfg).Print(root); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"iter"
+	"os"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/lexer"
+	"github.com/lastvoidtemplar/json_formatter/internal/token"
+)
+
+// the tokens of a file and its content, which is needed for the diagnostics,
+// -check, -diff and -write
+type input struct {
+	// nil when the file is streamed
+	src []byte
+	lex iter.Seq[token.Token]
+	// the content for -ndjson, which lexes every line on its own
+	r     io.Reader
+	close func() error
+}
+
+func noClose() error {
+	return nil
+}
+
+// opens the file ("-" is stdin). with -mmap regular files are memory-mapped.
+// with -stream, or with -mmap when only the output is printed, the files which
+// aren't mapped are streamed, otherwise the file is read
+func (a *app) open(name string) (*input, error) {
+	lexOpts := a.opts.lexerOptions()
+
+	var r io.Reader = a.stdin
+	closeFile := noClose
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		r = f
+		closeFile = f.Close
+	}
+
+	if f, ok := r.(*os.File); ok && a.opts.mmap {
+		m, err := lexer.Mmap(f)
+		switch {
+		case err == nil:
+			// the mapping stays valid after the file is closed
+			closeFile()
+			in := &input{
+				src:   m.Bytes(),
+				lex:   lexer.NewMapped(m, lexOpts),
+				r:     bytes.NewReader(m.Bytes()),
+				close: m.Close,
+			}
+			return in, nil
+		case !errors.Is(err, lexer.ErrNotMappable):
+			closeFile()
+			return nil, err
+		}
+	}
+
+	if a.opts.stream || a.opts.mmap && !a.opts.list && !a.opts.diff && !a.opts.write {
+		return &input{lex: lexer.NewWithOptions(r, lexOpts), r: r, close: closeFile}, nil
+	}
+
+	src, err := io.ReadAll(r)
+	closeFile()
+	if err != nil {
+		return nil, err
+	}
+	in := &input{
+		src:   src,
+		lex:   lexer.NewBytesWithOptions(src, lexOpts),
+		r:     bytes.NewReader(src),
+		close: noClose,
+	}
+	return in, nil
+}
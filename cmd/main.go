@@ -1,7 +1,7 @@
 package main
 
-import "fmt"
+import "os"
 
 func main() {
-	fmt.Println("Json formatter")
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
 }
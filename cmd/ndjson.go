@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/lexer"
+	"github.com/lastvoidtemplar/json_formatter/internal/parser"
+	"github.com/lastvoidtemplar/json_formatter/internal/printer"
+)
+
+// formats every line of newline delimited json as its own document into w.
+// lines without a value are skipped. the records with syntax errors are not
+// written, report gets the record and its errors, which have the rows of the
+// whole input and offsets in the record. returns the read and write errors
+func formatRecords(r io.Reader, w io.Writer, cfg printer.Config, lexOpts lexer.Options, opts parser.Options,
+	report func(record []byte, err error)) error {
+	br := bufio.NewReader(r)
+
+	row := 1
+	for {
+		line, readErr := br.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+
+		// without the newline the errors at the end of the record stay on its row
+		record := bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))
+		res, err := format(lexer.NewBytesWithOptions(record, lexOpts), cfg, opts)
+		switch {
+		case err == nil:
+			if _, err := w.Write(res); err != nil {
+				return err
+			}
+		case errors.Is(err, parser.ErrEmptyLexer):
+		default:
+			if !moveToRow(err, row) {
+				return err
+			}
+			report(record, err)
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+		row++
+	}
+}
+
+// moves the syntax errors of the record on row to the rows of the whole input
+func moveToRow(err error, row int) bool {
+	var errs parser.ParserErrors
+	var parserErr *parser.ParserError
+
+	switch {
+	case errors.As(err, &errs):
+	case errors.As(err, &parserErr):
+		errs = parser.ParserErrors{parserErr}
+	default:
+		return false
+	}
+
+	for _, err := range errs {
+		err.Row += row - 1
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+type result struct {
+	out    bytes.Buffer
+	stderr bytes.Buffer
+	log    *errorLog
+	code   int
+}
+
+// formats the files with opts.jobs workers. every file writes into its own
+// buffers and error log which are flushed in the order of files, so the output
+// does not depend on the scheduling
+func (a *app) processAll(files []walkEntry, out io.Writer, log *errorLog) int {
+	results := make([]chan *result, len(files))
+	for i := range results {
+		results[i] = make(chan *result, 1)
+	}
+
+	// limits how far the workers can get ahead of the flushing
+	window := make(chan struct{}, 2*a.opts.jobs)
+	jobs := make(chan int)
+
+	go func() {
+		defer close(jobs)
+		for i := range files {
+			window <- struct{}{}
+			jobs <- i
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range a.opts.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				res := &result{}
+				res.log = log.fork(&res.stderr)
+				if err := files[i].err; err != nil {
+					res.log.report(displayName(files[i].name), nil, err)
+					res.code = exitIOErr
+				} else {
+					res.code = a.processPath(files[i].name, &res.out, res.log)
+				}
+				results[i] <- res
+			}
+		}()
+	}
+
+	code := exitOK
+	for i := range files {
+		res := <-results[i]
+		<-window
+
+		if _, err := res.out.WriteTo(out); err != nil {
+			res.log.report("", nil, err)
+			res.code = exitIOErr
+		}
+		res.stderr.WriteTo(log.w)
+		log.merge(res.log)
+		code = max(code, res.code)
+	}
+
+	wg.Wait()
+	return code
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bufio"
+	"io"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/parser"
+	"github.com/lastvoidtemplar/json_formatter/internal/printer"
+)
+
+// formats the files one by one straight into out. unlike processAll nothing
+// is buffered, so the files can't be formatted in parallel
+func (a *app) streamAll(files []walkEntry, out io.Writer, log *errorLog) int {
+	w := bufio.NewWriter(out)
+
+	code := exitOK
+	for _, file := range files {
+		// the output before the error is already flushed
+		if file.err != nil {
+			log.report(displayName(file.name), nil, file.err)
+			code = exitIOErr
+			continue
+		}
+		code = max(code, a.streamPath(file.name, w, log))
+
+		if err := w.Flush(); err != nil {
+			log.report("", nil, err)
+			return exitIOErr
+		}
+	}
+	return code
+}
+
+func (a *app) streamPath(name string, out *bufio.Writer, log *errorLog) int {
+	// the errors on stderr follow the output before them
+	report := func(src []byte, err error) {
+		out.Flush()
+		log.report(displayName(name), src, err)
+	}
+
+	in, err := a.open(name)
+	if err != nil {
+		report(nil, err)
+		return exitIOErr
+	}
+	defer in.close()
+
+	// the records are small, each of them is formatted in memory
+	if a.opts.ndjson {
+		code := exitOK
+		err := formatRecords(in.r, out, a.cfg, a.opts.lexerOptions(), a.opts.parserOptions(), func(record []byte, err error) {
+			report(record, err)
+			code = exitSyntaxErr
+		})
+		if err != nil {
+			report(nil, err)
+			return exitIOErr
+		}
+		return code
+	}
+
+	// the keys of the open objects would stay in memory to find the duplicates
+	opts := a.opts.parserOptions()
+	opts.AllowDuplicateKeys = true
+	p, err := parser.NewWithOptions(in.lex, opts)
+	if err != nil {
+		report(in.src, err)
+		return exitCode(err)
+	}
+
+	// the flags which the stream doesn't support are rejected by parseFlags
+	s, err := printer.NewStream(out, a.cfg)
+	if err != nil {
+		report(nil, err)
+		return exitUsageErr
+	}
+
+	if err := s.Print(p.Events()); err != nil {
+		// the output is cut, the next file starts on its own line
+		io.WriteString(out, a.cfg.Newline)
+		report(in.src, err)
+		return exitCode(err)
+	}
+	return exitOK
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+var defaultInclude = []string{"*.json"}
+
+// directories which are never walked into
+var skipDirs = map[string]struct{}{
+	".git":         {},
+	"node_modules": {},
+	"vendor":       {},
+}
+
+// flag.Value for flags which can be repeated
+type stringList []string
+
+func (list *stringList) String() string {
+	return strings.Join(*list, ",")
+}
+
+func (list *stringList) Set(val string) error {
+	*list = append(*list, val)
+	return nil
+}
+
+// the pattern is matched against the base name and against the slash separated
+// path relative to the walked directory
+func matchAny(patterns []string, rel string) bool {
+	rel = filepath.ToSlash(rel)
+	base := filepath.Base(rel)
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, base); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// a file to format or an error of the walk, at its place among the files
+type walkEntry struct {
+	name string
+	err  error
+}
+
+// replaces the directories in paths with the matching files beneath them in
+// lexical order. files given explicitly are always kept. the errors are kept
+// in the order of the walk, so they are reported when their path is reached
+func expandPaths(paths []string, include []string, exclude []string) []walkEntry {
+	if len(include) == 0 {
+		include = defaultInclude
+	}
+
+	files := make([]walkEntry, 0, len(paths))
+
+	for _, root := range paths {
+		if root == "-" {
+			files = append(files, walkEntry{name: root})
+			continue
+		}
+
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				files = append(files, walkEntry{name: path, err: err})
+				return nil
+			}
+
+			if path == root {
+				if !d.IsDir() {
+					files = append(files, walkEntry{name: path})
+				}
+				return nil
+			}
+
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+
+			if d.IsDir() {
+				if _, ok := skipDirs[d.Name()]; ok || matchAny(exclude, rel) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			if matchAny(include, rel) && !matchAny(exclude, rel) {
+				files = append(files, walkEntry{name: path})
+			}
+			return nil
+		})
+
+		if err != nil {
+			files = append(files, walkEntry{name: root, err: fmt.Errorf("%s: %w", root, err)})
+		}
+	}
+
+	return files
+}
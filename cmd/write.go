@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// a temp file in the same directory which replaces the file on commit, so the
+// file is either fully old or fully new. keeps the permissions of an existing
+// file, a symlink is kept and its target is replaced
+type atomicFile struct {
+	*os.File
+	name string
+	// nil for a new file
+	info fs.FileInfo
+}
+
+func createAtomic(name string) (*atomicFile, error) {
+	if target, err := filepath.EvalSymlinks(name); err == nil {
+		name = target
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	info, err := os.Stat(name)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	return &atomicFile{File: tmp, name: name, info: info}, nil
+}
+
+func (f *atomicFile) commit() (err error) {
+	defer func() {
+		if err != nil {
+			f.abort()
+		}
+	}()
+
+	var perm os.FileMode
+	if f.info != nil {
+		perm = f.info.Mode().Perm()
+	} else if perm, err = createPerm(f.name); err != nil {
+		return err
+	}
+
+	if err = f.Chmod(perm); err != nil {
+		return err
+	}
+
+	if err = f.Sync(); err != nil {
+		return err
+	}
+
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), f.name)
+}
+
+// removes the temp file, the file stays as it was
+func (f *atomicFile) abort() {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// the permissions which os.Create gives the new file name under the umask.
+// the file is created empty and replaced right after
+func createPerm(name string) (os.FileMode, error) {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Mode().Perm(), nil
+}
+
+// replaces the file content through an atomicFile
+func writeFileAtomic(name string, content []byte) error {
+	if _, err := os.Stat(name); err != nil {
+		return err
+	}
+
+	f, err := createAtomic(name)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(content); err != nil {
+		f.abort()
+		return err
+	}
+	return f.commit()
+}
@@ -23,6 +23,50 @@ const (
 
 type Node interface {
 	NodeType() NodeType
+	NodeComments() *Comments
+	NodeSpan() Span
+}
+
+// part of the input taken by a node without its comments, End is right after the node
+type Span struct {
+	Start token.Position
+	End   token.Position
+}
+
+func (span Span) NodeSpan() Span {
+	return span
+}
+
+func TokenSpan(tok token.Token) Span {
+	return Span{
+		Start: tok.Start(),
+		End:   tok.End(),
+	}
+}
+
+type Comment struct {
+	Token token.Token
+	// the comment is the first token on its line
+	NewLine bool
+}
+
+func (comment Comment) IsLine() bool {
+	return comment.Token.Type == token.LINE_COMMENT
+}
+
+// comments attached to a node. for array elements and keyvals the trailing
+// comments go after the comma
+type Comments struct {
+	Leading  []Comment
+	Trailing []Comment
+}
+
+func (comments *Comments) NodeComments() *Comments {
+	return comments
+}
+
+func (comments *Comments) Empty() bool {
+	return len(comments.Leading) == 0 && len(comments.Trailing) == 0
 }
 
 type LeafNode interface {
@@ -32,6 +76,8 @@ type LeafNode interface {
 }
 
 type NullNode struct {
+	Comments
+	Span
 	Type  NodeType
 	Token token.Token
 }
@@ -46,6 +92,8 @@ func (node *NullNode) Literal() string {
 func (node *NullNode) leatNode() {}
 
 type BoolNode struct {
+	Comments
+	Span
 	Type  NodeType
 	Token token.Token
 }
@@ -61,6 +109,8 @@ func (node *BoolNode) Literal() string {
 func (node *BoolNode) leatNode() {}
 
 type NumberNode struct {
+	Comments
+	Span
 	Type  NodeType
 	Token token.Token
 }
@@ -76,6 +126,8 @@ func (node *NumberNode) Literal() string {
 func (node *NumberNode) leatNode() {}
 
 type StringNode struct {
+	Comments
+	Span
 	Type  NodeType
 	Token token.Token
 }
@@ -91,6 +143,8 @@ func (node *StringNode) Literal() string {
 func (node *StringNode) leatNode() {}
 
 type UndefinedNode struct {
+	Comments
+	Span
 	Type  NodeType
 	Token token.Token
 }
@@ -108,21 +162,25 @@ func (node *UndefinedNode) leatNode() {}
 func NewLeafNode(tok token.Token) LeafNode {
 	switch tok.Type {
 	case token.NULL:
-		return &NullNode{Type: NULL, Token: tok}
+		return &NullNode{Span: TokenSpan(tok), Type: NULL, Token: tok}
 	case token.TRUE, token.FALSE:
-		return &BoolNode{Type: BOOL, Token: tok}
+		return &BoolNode{Span: TokenSpan(tok), Type: BOOL, Token: tok}
 	case token.NUMBER_LITERAL:
-		return &NumberNode{Type: NUMBER, Token: tok}
-	case token.STRING_LITERAL:
-		return &StringNode{Type: STRING, Token: tok}
+		return &NumberNode{Span: TokenSpan(tok), Type: NUMBER, Token: tok}
+	case token.STRING_LITERAL, token.SINGLE_STRING_LITERAL:
+		return &StringNode{Span: TokenSpan(tok), Type: STRING, Token: tok}
 	default:
 		return nil
 	}
 }
 
 type ArrayNode struct {
+	Comments
+	Span
 	Type  NodeType
 	Nodes []Node
+	// comments before the closing bracket
+	Dangling []Comment
 }
 
 func (array *ArrayNode) NodeType() NodeType {
@@ -146,6 +204,8 @@ func NewArrayNode() *ArrayNode {
 }
 
 type KeyValNode struct {
+	Comments
+	Span
 	Type NodeType
 	Key  token.Token
 	Val  Node
@@ -161,6 +221,7 @@ func NewKeyVal(key token.Token, val Node) *KeyValNode {
 	}
 
 	return &KeyValNode{
+		Span: Span{Start: key.Start(), End: val.NodeSpan().End},
 		Type: KEYVAL,
 		Key:  key,
 		Val:  val,
@@ -168,9 +229,13 @@ func NewKeyVal(key token.Token, val Node) *KeyValNode {
 }
 
 type ObjectNode struct {
+	Comments
+	Span
 	Type  NodeType
 	Nodes []*KeyValNode
-	keys  map[string]struct{}
+	// comments before the closing bracket
+	Dangling []Comment
+	keys     map[string]struct{}
 }
 
 func (array *ObjectNode) NodeType() NodeType {
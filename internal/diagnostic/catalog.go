@@ -0,0 +1,184 @@
+package diagnostic
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/parser"
+)
+
+// code of the errors which are not from the parser
+const CodeIO = "JF2001"
+
+// kind of an error, Err is matched with errors.Is
+type Rule struct {
+	Err  error
+	Code string
+	Name string
+	// single sentence
+	Description string
+	// the long description printed by explain
+	Explanation string
+	// input which causes the error and its fixed version, empty if there is none
+	Example string
+	Fixed   string
+}
+
+// the last rule matches every error which is not from the parser
+var Rules = []Rule{
+	{
+		Err:         parser.ErrExtraTokens,
+		Name:        "extra-tokens",
+		Description: "The document continues after the root value.",
+		Explanation: "A JSON document holds exactly one value. Anything after the end of the root\n" +
+			"array, object or literal, other than whitespace and comments, is an error.\n" +
+			"Several values have to be wrapped in an array.",
+		Example: `{"id": 1} {"id": 2}`,
+		Fixed:   `[{"id": 1}, {"id": 2}]`,
+	},
+	{
+		Err:         parser.ErrInvalidType,
+		Name:        "invalid-value",
+		Description: "A value is not a string, number, true, false, null, array or object.",
+		Explanation: "Values are double quoted strings, numbers, the literals true, false and null,\n" +
+			"arrays and objects. The literals are lower case, strings can't be single\n" +
+			"quoted and a comma must be followed by another element. Trailing commas are\n" +
+			"accepted only with -allow-trailing-commas or in JSON5.",
+		Example: `{"enabled": True, "tags": ["a",]}`,
+		Fixed:   `{"enabled": true, "tags": ["a"]}`,
+	},
+	{
+		Err:         parser.ErrMissingArrayClosingBracket,
+		Name:        "missing-array-closing-bracket",
+		Description: "An array is not closed with ']'.",
+		Explanation: "The document ended before the ']' which closes the array. When all errors\n" +
+			"are reported, it is also reported for an array closed with '}'.",
+		Example: `{"ids": [1, 2`,
+		Fixed:   `{"ids": [1, 2]}`,
+	},
+	{
+		Err:         parser.ErrMissingArraySeparator,
+		Name:        "missing-array-separator",
+		Description: "Array elements are not separated with ','.",
+		Explanation: "Every element of an array except the last one must be followed by a comma.\n" +
+			"The error is also reported when the array is closed with '}' instead of ']'.",
+		Example: `[1 2, 3]`,
+		Fixed:   `[1, 2, 3]`,
+	},
+	{
+		Err:         parser.ErrMissingObjectClosingBracket,
+		Name:        "missing-object-closing-bracket",
+		Description: "An object is not closed with '}'.",
+		Explanation: "The document ended before the '}' which closes the object. When all errors\n" +
+			"are reported, it is also reported for an object closed with ']'.",
+		Example: `[{"id": 1`,
+		Fixed:   `[{"id": 1}]`,
+	},
+	{
+		Err:         parser.ErrMissingObjectSeparator,
+		Name:        "missing-object-separator",
+		Description: "Object members are not separated with ','.",
+		Explanation: "Every member of an object except the last one must be followed by a comma.\n" +
+			"The error is also reported when the object is closed with ']' instead of '}'.",
+		Example: `{"id": 1 "name": "a"}`,
+		Fixed:   `{"id": 1, "name": "a"}`,
+	},
+	{
+		Err:         parser.ErrDuplicateKeys,
+		Name:        "duplicate-keys",
+		Description: "An object contains the same key more than once.",
+		Explanation: "Parsers disagree which of the duplicate keys wins, some take the first\n" +
+			"value and some the last one, so every key may appear only once in an object.",
+		Example: `{"id": 1, "id": 2}`,
+		Fixed:   `{"id": 2}`,
+	},
+	{
+		Err:         parser.ErrKeyNotString,
+		Name:        "key-not-string",
+		Description: "An object key is not a string.",
+		Explanation: "Object keys must be double quoted strings. Unquoted and single quoted keys\n" +
+			"are accepted only in JSON5.",
+		Example: `{id: 1}`,
+		Fixed:   `{"id": 1}`,
+	},
+	{
+		Err:         parser.ErrMissingKeyvalSeparator,
+		Name:        "missing-keyval-separator",
+		Description: "A key is not followed by ':'.",
+		Explanation: "Every key of an object must be followed by a colon and its value.",
+		Example:     `{"id" 1}`,
+		Fixed:       `{"id": 1}`,
+	},
+	{
+		Err:         parser.ErrEmptyLexer,
+		Name:        "empty-document",
+		Description: "The document does not contain a value.",
+		Explanation: "The input is empty or contains only whitespace and comments. An empty\n" +
+			"document is written as {} or [].",
+		Example: `// nothing here`,
+		Fixed:   `{}`,
+	},
+	{
+		Err:         parser.ErrInvalidUTF8,
+		Name:        "invalid-utf8",
+		Description: "The document is not valid UTF-8.",
+		Explanation: "JSON text must be encoded in UTF-8. The error points at the token which\n" +
+			"contains bytes that are not valid UTF-8, usually a string written by a tool\n" +
+			"which used Latin-1 or Windows-1252. Save the file again as UTF-8.",
+	},
+	{
+		Code:        CodeIO,
+		Name:        "io-error",
+		Description: "The file could not be read or written.",
+		Explanation: "The file does not exist, it can't be read or the formatted output can't be\n" +
+			"written. The message contains the reason reported by the operating system.",
+	},
+}
+
+func init() {
+	for i := range Rules {
+		var coded parser.CodedError
+		if errors.As(Rules[i].Err, &coded) {
+			Rules[i].Code = coded.Code()
+		}
+	}
+}
+
+func ruleIndex(err error) int {
+	for i, rule := range Rules[:len(Rules)-1] {
+		if errors.Is(err, rule.Err) {
+			return i
+		}
+	}
+	return len(Rules) - 1
+}
+
+// code of the rule which matches the error
+func Code(err error) string {
+	return Rules[ruleIndex(err)].Code
+}
+
+// the rule with the code, the code is case insensitive
+func Lookup(code string) (Rule, bool) {
+	for _, rule := range Rules {
+		if strings.EqualFold(rule.Code, code) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+// writes the long description of the rule with its example
+func Explain(w io.Writer, rule Rule) error {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%s %s\n\n%s\n\n%s\n", rule.Code, rule.Name, rule.Description, rule.Explanation)
+	if rule.Example != "" {
+		fmt.Fprintf(&sb, "\nInvalid:\n\n    %s\n\nValid:\n\n    %s\n", rule.Example, rule.Fixed)
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,77 @@
+package diagnostic
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/lexer"
+	"github.com/lastvoidtemplar/json_formatter/internal/parser"
+)
+
+func TestRuleCodes(t *testing.T) {
+	codes := map[string]bool{}
+	for _, rule := range Rules {
+		if !strings.HasPrefix(rule.Code, "JF") || len(rule.Code) != 6 {
+			t.Errorf("Invalid code %q of %s", rule.Code, rule.Name)
+		}
+		if codes[rule.Code] {
+			t.Errorf("Duplicate code %s", rule.Code)
+		}
+		codes[rule.Code] = true
+	}
+
+	var coded parser.CodedError
+	if !errors.As(parseErr("[1 2]", t), &coded) || coded.Code() != "JF1004" {
+		t.Fatalf("Expected ParserError with code JF1004")
+	}
+}
+
+// every example must fail with its own rule and the fixed version must pass
+func TestRuleExamples(t *testing.T) {
+	for _, rule := range Rules {
+		if rule.Example == "" {
+			continue
+		}
+
+		p, err := parser.New(lexer.New(strings.NewReader(rule.Example)))
+		if err == nil {
+			_, err = p.Parse()
+		}
+
+		if Code(err) != rule.Code {
+			t.Errorf("Expected %s for the example of %s, but got %v", rule.Code, rule.Name, err)
+		}
+
+		p, err = parser.New(lexer.New(strings.NewReader(rule.Fixed)))
+		if err == nil {
+			_, err = p.Parse()
+		}
+
+		if err != nil {
+			t.Errorf("Expected the fixed example of %s to be valid, but got %v", rule.Name, err)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	rule, ok := Lookup("jf1007")
+	if !ok {
+		t.Fatal("Expected JF1007 to be found")
+	}
+
+	var sb strings.Builder
+	if err := Explain(&sb, rule); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{"JF1007 duplicate-keys\n", "Invalid:\n\n    {\"id\": 1, \"id\": 2}\n", "Valid:\n\n    {\"id\": 2}\n"} {
+		if !strings.Contains(sb.String(), expected) {
+			t.Errorf("Expected %q in\n%s", expected, sb.String())
+		}
+	}
+
+	if _, ok := Lookup("JF9999"); ok {
+		t.Error("Expected JF9999 to be unknown")
+	}
+}
@@ -0,0 +1,170 @@
+package diagnostic
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/parser"
+)
+
+// renders parser errors together with the line of the source, a marker
+// under the bad token and a hint how to fix it:
+//
+//	config.json:3:10: expected STRING, NUMBER, TRUE, FALSE, NULL, '[' or '{', but got ture [JF1002]
+//	  3 |     "a": ture,
+//	    |          ^~~~
+//	    = hint: did you mean `true`?
+type Renderer struct {
+	w    io.Writer
+	name string
+	src  []byte
+	err  error
+}
+
+func New(w io.Writer, name string, src []byte) *Renderer {
+	return &Renderer{
+		w:    w,
+		name: name,
+		src:  src,
+	}
+}
+
+// renders every error of ParserErrors, other errors are written on a single line.
+// src can be nil, then only the first line of the syntax errors is written.
+// returns the first write error
+func (r *Renderer) Render(err error) error {
+	var parserErrs parser.ParserErrors
+	var parserErr *parser.ParserError
+
+	switch {
+	case errors.As(err, &parserErrs):
+		for _, parserErr := range parserErrs {
+			r.renderParserErr(parserErr)
+		}
+	case errors.As(err, &parserErr):
+		r.renderParserErr(parserErr)
+	default:
+		var coded parser.CodedError
+		if errors.As(err, &coded) {
+			r.printf("%s: %s [%s]\n", r.name, err, coded.Code())
+		} else {
+			r.printf("%s: %s\n", r.name, err)
+		}
+	}
+
+	err, r.err = r.err, nil
+	return err
+}
+
+func (r *Renderer) renderParserErr(err *parser.ParserError) {
+	r.printf("%s:%d:%d: %s [%s]\n", r.name, err.Row, err.Colm, Message(err), Code(err))
+
+	// streamed input has no source to show
+	line, start, ok := lineAt(r.src, err.Offset)
+	if !ok || r.src == nil {
+		return
+	}
+
+	num := strconv.Itoa(err.Row)
+	gutter := strings.Repeat(" ", len(num))
+
+	r.printf(" %s | %s\n", num, bytes.ToValidUTF8(line, []byte("\uFFFD")))
+	r.printf(" %s | %s\n", gutter, marker(line, start))
+
+	if hint := hint(r.src, err); hint != "" {
+		r.printf(" %s = hint: %s\n", gutter, hint)
+	}
+}
+
+func (r *Renderer) printf(format string, args ...any) {
+	if r.err != nil {
+		return
+	}
+	_, r.err = fmt.Fprintf(r.w, format, args...)
+}
+
+// the error without the position, which is already shown by the diagnostic
+func Message(err *parser.ParserError) string {
+	if err.Actual == "" {
+		return err.WrapError.Error()
+	}
+	return fmt.Sprintf("%s, but got %s", err.WrapError.Error(), strings.ToValidUTF8(err.Actual, "\uFFFD"))
+}
+
+// the text before start is replaced with spaces, so the marker stays aligned with tabs
+func marker(line []byte, start int) string {
+	var sb strings.Builder
+	for _, r := range string(line[:start]) {
+		if r == '\t' {
+			sb.WriteByte('\t')
+		} else {
+			sb.WriteByte(' ')
+		}
+	}
+
+	sb.WriteByte('^')
+	width := utf8.RuneCount(line[start : start+tokenLen(line[start:])])
+	for range width - 1 {
+		sb.WriteByte('~')
+	}
+
+	return sb.String()
+}
+
+// length in bytes of the token at the start of the line
+func tokenLen(line []byte) int {
+	if len(line) == 0 {
+		return 0
+	}
+
+	switch quote := line[0]; {
+	case quote == '"' || quote == '\'':
+		for i := 1; i < len(line); i++ {
+			if line[i] == '\\' {
+				i++
+			} else if line[i] == quote {
+				return i + 1
+			}
+		}
+		return len(line)
+	case isDelim(quote):
+		return 1
+	}
+
+	i := 0
+	for i < len(line) && !isDelim(line[i]) && !isSpace(line[i]) && line[i] != '"' && line[i] != '\'' {
+		i++
+	}
+	return i
+}
+
+func isDelim(ch byte) bool {
+	return ch == '[' || ch == ']' || ch == '{' || ch == '}' || ch == ',' || ch == ':'
+}
+
+func isSpace(ch byte) bool {
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
+}
+
+// the line which contains the offset without its newline and the index of the offset in it
+func lineAt(src []byte, offset int) ([]byte, int, bool) {
+	if offset < 0 || offset > len(src) {
+		return nil, 0, false
+	}
+
+	start := bytes.LastIndexByte(src[:offset], '\n') + 1
+	end := bytes.IndexByte(src[offset:], '\n')
+	if end == -1 {
+		end = len(src)
+	} else {
+		end += offset
+	}
+
+	line := bytes.TrimSuffix(src[start:end], []byte("\r"))
+	return line, min(offset-start, len(line)), true
+}
@@ -0,0 +1,137 @@
+package diagnostic
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/lexer"
+	"github.com/lastvoidtemplar/json_formatter/internal/parser"
+	"github.com/lastvoidtemplar/json_formatter/internal/token"
+)
+
+func parseErr(input string, t *testing.T) error {
+	p, err := parser.NewWithOptions(lexer.New(strings.NewReader(input)), parser.Options{Recover: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = p.Parse()
+	if err == nil {
+		t.Fatal("Expected parsing error")
+	}
+	return err
+}
+
+func render(input string, t *testing.T) string {
+	var sb strings.Builder
+	if err := New(&sb, "test.json", []byte(input)).Render(parseErr(input, t)); err != nil {
+		t.Fatal(err)
+	}
+	return sb.String()
+}
+
+func TestRenderSnippet(t *testing.T) {
+	input := "{\n\t\"a\": ture\n}"
+
+	expected := "test.json:2:7: expected STRING, NUMBER, TRUE, FALSE, NULL, '[' or '{', but got ture [JF1002]\n" +
+		" 2 | \t\"a\": ture\n" +
+		"   | \t     ^~~~\n" +
+		"   = hint: did you mean `true`?\n"
+
+	if actual := render(input, t); actual != expected {
+		t.Fatalf("Expected\n%s\nbut got\n%s", expected, actual)
+	}
+}
+
+func TestRenderStringMarker(t *testing.T) {
+	input := `[1 "héllo"]`
+
+	expected := "test.json:1:4: expected SEMICOLON or ']', but got héllo [JF1004]\n" +
+		" 1 | [1 \"héllo\"]\n" +
+		"   |    ^~~~~~~\n" +
+		"   = hint: missing comma between elements\n"
+
+	if actual := render(input, t); actual != expected {
+		t.Fatalf("Expected\n%s\nbut got\n%s", expected, actual)
+	}
+}
+
+func TestRenderAllErrors(t *testing.T) {
+	actual := render("[nul, 1,]\n[2]", t)
+
+	for _, expected := range []string{
+		"test.json:1:2: ",
+		"did you mean `null`?",
+		"test.json:1:9: ",
+		"remove the trailing comma",
+		"test.json:2:1: expected EOF, but got [ [JF1001]",
+	} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("Expected %q in\n%s", expected, actual)
+		}
+	}
+}
+
+func TestRenderOtherErr(t *testing.T) {
+	var sb strings.Builder
+	New(&sb, "test.json", nil).Render(errors.New("boom"))
+
+	if sb.String() != "test.json: boom\n" {
+		t.Fatalf("Expected plain error, but got %q", sb.String())
+	}
+}
+
+func TestHint(t *testing.T) {
+	tests := []struct {
+		input string
+		hint  string
+	}{
+		{`[False]`, "did you mean `false`?"},
+		{`[fasle]`, "did you mean `false`?"},
+		{`[maybe]`, "strings must be double quoted"},
+		{`['a']`, "strings must be double quoted, single quotes are valid only in JSON5"},
+		{`[1,,2]`, "missing value between the commas"},
+		{`{"a" 1}`, "missing ':' between the key and its value"},
+		{`{key: 1}`, "keys must be double quoted, write `\"key\"`"},
+		{`{"a": 1 "b": 2}`, "missing comma between members"},
+		{`{"a": 1, "a": 2}`, "rename or remove one of the keys"},
+		{`{"a": [1}`, "add ']' to close the array"},
+	}
+
+	for _, test := range tests {
+		var parserErr *parser.ParserError
+		if !errors.As(parseErr(test.input, t), &parserErr) {
+			t.Fatalf("Expected ParserError for %s", test.input)
+		}
+
+		if actual := hint([]byte(test.input), parserErr); actual != test.hint {
+			t.Errorf("Expected hint %q for %s, but got %q", test.hint, test.input, actual)
+		}
+	}
+}
+
+// the marker is placed by the byte offset, so it doesn't depend on the column unit
+func TestRenderColumnUnits(t *testing.T) {
+	input := `{"😀": nul}`
+
+	lex := lexer.NewWithOptions(strings.NewReader(input), lexer.Options{Columns: token.UTF16_CODE_UNITS})
+	p, err := parser.New(lex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = p.Parse()
+
+	var sb strings.Builder
+	New(&sb, "test.json", []byte(input)).Render(err)
+
+	expected := "test.json:1:8: expected STRING, NUMBER, TRUE, FALSE, NULL, '[' or '{', but got nul [JF1002]\n" +
+		" 1 | {\"😀\": nul}\n" +
+		"   |       ^~~\n" +
+		"   = hint: did you mean `null`?\n"
+
+	if sb.String() != expected {
+		t.Fatalf("Expected\n%s\nbut got\n%s", expected, sb.String())
+	}
+}
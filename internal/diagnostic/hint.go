@@ -0,0 +1,141 @@
+package diagnostic
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/parser"
+)
+
+// literals suggested for misspelled values
+var keywords = []string{"true", "false", "null"}
+
+// suggestion how to fix the error, empty if there is none
+func hint(src []byte, err *parser.ParserError) string {
+	switch {
+	case errors.Is(err, parser.ErrInvalidType):
+		return invalidTypeHint(src, err)
+	case errors.Is(err, parser.ErrMissingArraySeparator):
+		if err.Actual == "}" {
+			return "add ']' to close the array"
+		}
+		return "missing comma between elements"
+	case errors.Is(err, parser.ErrMissingObjectSeparator):
+		if err.Actual == "]" {
+			return "add '}' to close the object"
+		}
+		return "missing comma between members"
+	case errors.Is(err, parser.ErrMissingKeyvalSeparator):
+		return "missing ':' between the key and its value"
+	case errors.Is(err, parser.ErrKeyNotString):
+		if isWord(err.Actual) {
+			return fmt.Sprintf("keys must be double quoted, write `\"%s\"`", err.Actual)
+		}
+		return "keys must be double quoted strings"
+	case errors.Is(err, parser.ErrDuplicateKeys):
+		return "rename or remove one of the keys"
+	case errors.Is(err, parser.ErrMissingArrayClosingBracket):
+		return "add ']' to close the array"
+	case errors.Is(err, parser.ErrMissingObjectClosingBracket):
+		return "add '}' to close the object"
+	case errors.Is(err, parser.ErrInvalidUTF8):
+		return "save the file as UTF-8"
+	case errors.Is(err, parser.ErrExtraTokens):
+		return "a document holds a single value, wrap the values in an array"
+	}
+	return ""
+}
+
+func invalidTypeHint(src []byte, err *parser.ParserError) string {
+	actual := err.Actual
+
+	if isWord(actual) {
+		if keyword, ok := closestKeyword(actual); ok {
+			return fmt.Sprintf("did you mean `%s`?", keyword)
+		}
+		return "strings must be double quoted"
+	}
+
+	switch {
+	case strings.HasPrefix(actual, "'"):
+		return "strings must be double quoted, single quotes are valid only in JSON5"
+	case actual == "]" || actual == "}":
+		if prev, ok := prevChar(src, err.Offset); ok && prev == ',' {
+			return "remove the trailing comma, it is not allowed in JSON"
+		}
+		return "missing value"
+	case actual == ",":
+		return "missing value between the commas"
+	case actual == "EOF":
+		return "the document ended before the value"
+	}
+	return ""
+}
+
+func isWord(s string) bool {
+	if s == "" || s == "EOF" {
+		return false
+	}
+
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// the keyword with the smallest edit distance, if it is close enough
+func closestKeyword(word string) (string, bool) {
+	lower := strings.ToLower(word)
+	best, bestDist := "", 3
+
+	for _, keyword := range keywords {
+		dist := editDistance(lower, keyword)
+		if dist < bestDist && dist < len(keyword)-1 {
+			best, bestDist = keyword, dist
+		}
+	}
+
+	return best, best != ""
+}
+
+// optimal string alignment distance, swapping two neighbouring characters costs 1
+func editDistance(a string, b string) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+
+	return prev[len(b)]
+}
+
+// the first non whitespace character before the offset
+func prevChar(src []byte, offset int) (byte, bool) {
+	for i := min(offset, len(src)) - 1; i >= 0; i-- {
+		if !isSpace(src[i]) {
+			return src[i], true
+		}
+	}
+	return 0, false
+}
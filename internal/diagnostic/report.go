@@ -0,0 +1,93 @@
+package diagnostic
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/parser"
+)
+
+// machine readable form of a single error. Row and Colm are 0 for errors without position
+type Error struct {
+	File    string `json:"file"`
+	Row     int    `json:"row"`
+	Colm    int    `json:"colm"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Actual  string `json:"actual,omitempty"`
+	Hint    string `json:"hint,omitempty"`
+
+	rule int
+	// columns of the marked token in utf-16 code units, as sarif counts them
+	startColm int
+	endColm   int
+}
+
+// converts err to a list of errors, ParserErrors gives one per syntax error
+func NewErrors(file string, src []byte, err error) []Error {
+	var parserErrs parser.ParserErrors
+	var parserErr *parser.ParserError
+
+	switch {
+	case errors.As(err, &parserErrs):
+	case errors.As(err, &parserErr):
+		parserErrs = parser.ParserErrors{parserErr}
+	default:
+		rule := ruleIndex(err)
+		return []Error{{
+			File:    file,
+			Code:    Rules[rule].Code,
+			Message: err.Error(),
+			rule:    rule,
+		}}
+	}
+
+	errs := make([]Error, len(parserErrs))
+	for i, parserErr := range parserErrs {
+		rule := ruleIndex(parserErr)
+		errs[i] = Error{
+			File:    file,
+			Row:     parserErr.Row,
+			Colm:    parserErr.Colm,
+			Code:    Rules[rule].Code,
+			Message: Message(parserErr),
+			Actual:  parserErr.Actual,
+			Hint:    hint(src, parserErr),
+			rule:    rule,
+		}
+
+		// streamed input has no source, the region is only the line
+		if line, start, ok := lineAt(src, parserErr.Offset); ok && src != nil {
+			end := start + tokenLen(line[start:])
+			errs[i].startColm = utf16Len(line[:start]) + 1
+			errs[i].endColm = utf16Len(line[:end]) + 1
+		}
+	}
+
+	return errs
+}
+
+func utf16Len(b []byte) int {
+	n := 0
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		n += utf16.RuneLen(r)
+		b = b[size:]
+	}
+	return n
+}
+
+// writes every error as a json object on its own line
+func WriteJSON(w io.Writer, errs []Error) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, err := range errs {
+		if encErr := enc.Encode(err); encErr != nil {
+			return encErr
+		}
+	}
+	return nil
+}
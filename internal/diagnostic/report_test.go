@@ -0,0 +1,100 @@
+package diagnostic
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewErrors(t *testing.T) {
+	input := `{"é": tru, "a": 1 "b": 2}`
+	errs := NewErrors("test.json", []byte(input), parseErr(input, t))
+
+	expected := []Error{
+		{File: "test.json", Row: 1, Colm: 8, Code: "JF1002", Actual: "tru", Hint: "did you mean `true`?"},
+		{File: "test.json", Row: 1, Colm: 20, Code: "JF1006", Actual: "b", Hint: "missing comma between members"},
+	}
+
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors, but got %d", len(expected), len(errs))
+	}
+
+	for i, err := range errs {
+		if err.File != expected[i].File || err.Row != expected[i].Row || err.Colm != expected[i].Colm ||
+			err.Code != expected[i].Code || err.Actual != expected[i].Actual || err.Hint != expected[i].Hint {
+			t.Errorf("error[%d] was expected to be %+v, but got %+v", i, expected[i], err)
+		}
+	}
+
+	// "é" takes 2 bytes, but a single utf-16 code unit
+	if errs[0].startColm != 7 || errs[0].endColm != 10 {
+		t.Errorf("Expected utf-16 columns 7-10, but got %d-%d", errs[0].startColm, errs[0].endColm)
+	}
+}
+
+func TestNewErrorsOther(t *testing.T) {
+	errs := NewErrors("test.json", nil, errors.New("permission denied"))
+
+	if len(errs) != 1 || errs[0].Code != CodeIO || errs[0].Message != "permission denied" || errs[0].Row != 0 {
+		t.Fatalf("Expected io-error, but got %+v", errs)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	input := `[1 2]`
+	var sb strings.Builder
+	if err := WriteJSON(&sb, NewErrors("test.json", []byte(input), parseErr(input, t))); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"file":"test.json","row":1,"colm":4,"code":"JF1004",` +
+		`"message":"expected SEMICOLON or ']', but got 2","actual":"2","hint":"missing comma between elements"}` + "\n"
+
+	if sb.String() != expected {
+		t.Fatalf("Expected %s, but got %s", expected, sb.String())
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	input := "{\n  \"a\": 1,\n  \"a\": 2\n}"
+	errs := NewErrors("dir/test file.json", []byte(input), parseErr(input, t))
+	errs = append(errs, NewErrors("", nil, errors.New("boom"))...)
+
+	var sb strings.Builder
+	if err := WriteSARIF(&sb, errs); err != nil {
+		t.Fatal(err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal([]byte(sb.String()), &log); err != nil {
+		t.Fatal(err)
+	}
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("Expected a single sarif 2.1.0 run, but got %+v", log)
+	}
+
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != len(Rules) || len(run.Results) != 2 {
+		t.Fatalf("Expected %d rules and 2 results, but got %d and %d", len(Rules), len(run.Tool.Driver.Rules), len(run.Results))
+	}
+
+	result := run.Results[0]
+	if result.RuleID != "JF1007" || run.Tool.Driver.Rules[result.RuleIndex].ID != result.RuleID {
+		t.Errorf("Expected duplicate-keys rule, but got %s at %d", result.RuleID, result.RuleIndex)
+	}
+
+	location := result.Locations[0].PhysicalLocation
+	if location.ArtifactLocation.URI != "dir/test%20file.json" {
+		t.Errorf("Expected escaped relative uri, but got %s", location.ArtifactLocation.URI)
+	}
+
+	if location.Region == nil || location.Region.StartLine != 3 || location.Region.StartColumn != 3 || location.Region.EndColumn != 6 {
+		t.Errorf("Expected region 3:3-6, but got %+v", location.Region)
+	}
+
+	if run.Results[1].RuleID != CodeIO || run.Results[1].Locations != nil {
+		t.Errorf("Expected io-error without location, but got %+v", run.Results[1])
+	}
+}
@@ -0,0 +1,154 @@
+package diagnostic
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	toolName     = "json_formatter"
+)
+
+// the subset of sarif 2.1.0 which is needed for the errors
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool     `json:"tool"`
+	ColumnKind string        `json:"columnKind"`
+	Results    []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	FullDescription  sarifMessage `json:"fullDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// writes a sarif log with a single run which contains all errors
+func WriteSARIF(w io.Writer, errs []Error) error {
+	rules := make([]sarifRule, len(Rules))
+	for i, rule := range Rules {
+		rules[i] = sarifRule{
+			ID:               rule.Code,
+			Name:             rule.Name,
+			ShortDescription: sarifMessage{Text: rule.Description},
+			FullDescription:  sarifMessage{Text: rule.Explanation},
+		}
+	}
+
+	results := make([]sarifResult, len(errs))
+	for i, err := range errs {
+		results[i] = sarifResult{
+			RuleID:    err.Code,
+			RuleIndex: err.rule,
+			Level:     "error",
+			Message:   sarifMessage{Text: sarifText(err)},
+		}
+
+		if err.File == "" {
+			continue
+		}
+
+		location := sarifLocation{
+			PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: fileURI(err.File)},
+			},
+		}
+
+		if err.Row > 0 {
+			region := &sarifRegion{StartLine: err.Row, StartColumn: err.startColm}
+			if err.endColm > err.startColm {
+				region.EndColumn = err.endColm
+			}
+			location.PhysicalLocation.Region = region
+		}
+
+		results[i].Locations = []sarifLocation{location}
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool:       sarifTool{Driver: sarifDriver{Name: toolName, Rules: rules}},
+			ColumnKind: "utf16CodeUnits",
+			Results:    results,
+		}},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+func sarifText(err Error) string {
+	if err.Hint == "" {
+		return err.Message
+	}
+	return err.Message + " (" + err.Hint + ")"
+}
+
+// relative paths stay relative to the directory of the run
+func fileURI(name string) string {
+	path := filepath.ToSlash(name)
+	if !filepath.IsAbs(name) {
+		return (&url.URL{Path: path}).String()
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return (&url.URL{Scheme: "file", Path: path}).String()
+}
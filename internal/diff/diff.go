@@ -0,0 +1,281 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// number of unchanged lines printed around every change
+const context = 3
+
+type opKind byte
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type edit struct {
+	kind opKind
+	line string
+}
+
+// pair of equal lines, a is the index in the old text and b in the new one
+type pair struct {
+	a int
+	b int
+}
+
+// returns unified diff between old and new or nil if they are equal
+func Unified(oldName string, newName string, old []byte, new []byte) []byte {
+	if bytes.Equal(old, new) {
+		return nil
+	}
+
+	a := splitLines(string(old))
+	b := splitLines(string(new))
+
+	var matches []pair
+	matchLines(a, b, 0, len(a), 0, len(b), &matches)
+	edits := toEdits(a, b, matches)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", oldName, newName)
+	writeHunks(&buf, edits)
+	return buf.Bytes()
+}
+
+// splits after every newline, the last line may be without newline
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// patience diff. matches the common prefix and suffix, then the lines which
+// are unique in both ranges and recurses between them
+func matchLines(a []string, b []string, alo int, ahi int, blo int, bhi int, matches *[]pair) {
+	for alo < ahi && blo < bhi && a[alo] == b[blo] {
+		*matches = append(*matches, pair{a: alo, b: blo})
+		alo++
+		blo++
+	}
+
+	suffix := 0
+	for alo < ahi-suffix && blo < bhi-suffix && a[ahi-suffix-1] == b[bhi-suffix-1] {
+		suffix++
+	}
+	ahi -= suffix
+	bhi -= suffix
+
+	if alo < ahi && blo < bhi {
+		anchors := uniqueAnchors(a, b, alo, ahi, blo, bhi)
+		// without anchors the range is left as deleted and inserted lines
+		if len(anchors) > 0 {
+			for _, anchor := range anchors {
+				matchLines(a, b, alo, anchor.a, blo, anchor.b, matches)
+				*matches = append(*matches, anchor)
+				alo = anchor.a + 1
+				blo = anchor.b + 1
+			}
+			matchLines(a, b, alo, ahi, blo, bhi, matches)
+		}
+	}
+
+	for i := range suffix {
+		*matches = append(*matches, pair{a: ahi + i, b: bhi + i})
+	}
+}
+
+type occurrence struct {
+	countA int
+	countB int
+	a      int
+	b      int
+}
+
+// finds the longest increasing sequence of lines which appear exactly once
+// in both ranges
+func uniqueAnchors(a []string, b []string, alo int, ahi int, blo int, bhi int) []pair {
+	occurrences := make(map[string]*occurrence)
+	for i := alo; i < ahi; i++ {
+		occ, ok := occurrences[a[i]]
+		if !ok {
+			occ = &occurrence{}
+			occurrences[a[i]] = occ
+		}
+		occ.countA++
+		occ.a = i
+	}
+
+	for i := blo; i < bhi; i++ {
+		occ, ok := occurrences[b[i]]
+		if !ok {
+			continue
+		}
+		occ.countB++
+		occ.b = i
+	}
+
+	unique := make([]pair, 0)
+	for _, occ := range occurrences {
+		if occ.countA == 1 && occ.countB == 1 {
+			unique = append(unique, pair{a: occ.a, b: occ.b})
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool {
+		return unique[i].a < unique[j].a
+	})
+
+	return longestIncreasing(unique)
+}
+
+// patience sorting by b, the pairs are already sorted by a
+func longestIncreasing(pairs []pair) []pair {
+	// tails[i] is the index of the smallest tail of an increasing sequence with length i+1
+	tails := make([]int, 0)
+	prev := make([]int, len(pairs))
+
+	for i, p := range pairs {
+		pos := sort.Search(len(tails), func(j int) bool {
+			return pairs[tails[j]].b > p.b
+		})
+
+		if pos > 0 {
+			prev[i] = tails[pos-1]
+		} else {
+			prev[i] = -1
+		}
+
+		if pos == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[pos] = i
+		}
+	}
+
+	res := make([]pair, len(tails))
+	if len(tails) == 0 {
+		return res
+	}
+
+	ind := tails[len(tails)-1]
+	for i := len(res) - 1; i >= 0; i-- {
+		res[i] = pairs[ind]
+		ind = prev[ind]
+	}
+	return res
+}
+
+func toEdits(a []string, b []string, matches []pair) []edit {
+	edits := make([]edit, 0, len(a)+len(b)-len(matches))
+
+	i, j := 0, 0
+	for _, m := range matches {
+		for ; i < m.a; i++ {
+			edits = append(edits, edit{kind: opDelete, line: a[i]})
+		}
+		for ; j < m.b; j++ {
+			edits = append(edits, edit{kind: opInsert, line: b[j]})
+		}
+		edits = append(edits, edit{kind: opEqual, line: a[i]})
+		i++
+		j++
+	}
+
+	for ; i < len(a); i++ {
+		edits = append(edits, edit{kind: opDelete, line: a[i]})
+	}
+	for ; j < len(b); j++ {
+		edits = append(edits, edit{kind: opInsert, line: b[j]})
+	}
+
+	return edits
+}
+
+// groups the edits in hunks, changes closer than 2*context lines share a hunk
+func writeHunks(buf *bytes.Buffer, edits []edit) {
+	n := len(edits)
+	// line numbers in the old and new text before edits[i]
+	oldLine, newLine := 0, 0
+
+	i := 0
+	for i < n {
+		if edits[i].kind == opEqual {
+			oldLine++
+			newLine++
+			i++
+			continue
+		}
+
+		start := max(i-context, 0)
+		for k := start; k < i; k++ {
+			// the context was already counted as equal lines
+			oldLine--
+			newLine--
+		}
+
+		end := i
+		equal := 0
+		for end < n {
+			if edits[end].kind == opEqual {
+				equal++
+				if equal > 2*context {
+					equal--
+					break
+				}
+			} else {
+				equal = 0
+			}
+			end++
+		}
+		end -= max(equal-context, 0)
+
+		oldCount, newCount := 0, 0
+		for _, e := range edits[start:end] {
+			if e.kind != opInsert {
+				oldCount++
+			}
+			if e.kind != opDelete {
+				newCount++
+			}
+		}
+
+		fmt.Fprintf(buf, "@@ -%s +%s @@\n", hunkRange(oldLine, oldCount), hunkRange(newLine, newCount))
+		for _, e := range edits[start:end] {
+			switch e.kind {
+			case opEqual:
+				buf.WriteByte(' ')
+			case opDelete:
+				buf.WriteByte('-')
+			case opInsert:
+				buf.WriteByte('+')
+			}
+			buf.WriteString(e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				buf.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		oldLine += oldCount
+		newLine += newCount
+		i = end
+	}
+}
+
+// line is the count of lines before the range, empty range points to the line before it
+func hunkRange(line int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", line)
+	}
+	return fmt.Sprintf("%d,%d", line+1, count)
+}
@@ -0,0 +1,105 @@
+package diff
+
+import (
+	"testing"
+)
+
+func TestUnifiedEqual(t *testing.T) {
+	res := Unified("a", "b", []byte("[1]\n"), []byte("[1]\n"))
+
+	if res != nil {
+		t.Fatalf("Expected nil, but got %q", res)
+	}
+}
+
+func TestUnifiedHunks(t *testing.T) {
+	old := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n"
+	new := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n14\n15\n16\n"
+
+	expected := `--- data.json.orig
++++ data.json
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -10,6 +10,6 @@
+ 10
+ 11
+ 12
+-13
+ 14
+ 15
++16
+`
+
+	res := string(Unified("data.json.orig", "data.json", []byte(old), []byte(new)))
+
+	if res != expected {
+		t.Fatalf("Expected:\n%s\nbut got:\n%s", expected, res)
+	}
+}
+
+func TestUnifiedMergesCloseChanges(t *testing.T) {
+	old := "a\nb\nc\nd\ne\nf\ng\nh\n"
+	new := "A\nb\nc\nd\ne\nf\ng\nH\n"
+
+	expected := `--- old
++++ new
+@@ -1,8 +1,8 @@
+-a
++A
+ b
+ c
+ d
+ e
+ f
+ g
+-h
++H
+`
+
+	res := string(Unified("old", "new", []byte(old), []byte(new)))
+
+	if res != expected {
+		t.Fatalf("Expected:\n%s\nbut got:\n%s", expected, res)
+	}
+}
+
+func TestUnifiedNoNewlineAtEnd(t *testing.T) {
+	old := `{"a":1}`
+	new := "{\n    \"a\": 1\n}\n"
+
+	expected := `--- old
++++ new
+@@ -1,1 +1,3 @@
+-{"a":1}
+\ No newline at end of file
++{
++    "a": 1
++}
+`
+
+	res := string(Unified("old", "new", []byte(old), []byte(new)))
+
+	if res != expected {
+		t.Fatalf("Expected:\n%s\nbut got:\n%s", expected, res)
+	}
+}
+
+func TestUnifiedEmptyOld(t *testing.T) {
+	expected := `--- old
++++ new
+@@ -0,0 +1,1 @@
++[]
+`
+
+	res := string(Unified("old", "new", nil, []byte("[]\n")))
+
+	if res != expected {
+		t.Fatalf("Expected:\n%s\nbut got:\n%s", expected, res)
+	}
+}
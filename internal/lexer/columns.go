@@ -0,0 +1,37 @@
+package lexer
+
+import "github.com/lastvoidtemplar/json_formatter/internal/token"
+
+// counts the column of the consumed input in unit. it looks only at single
+// bytes, so the input can be given in any pieces
+type columns struct {
+	unit token.ColumnUnit
+	colm int
+}
+
+func (cols *columns) advance(s string) {
+	for i := 0; i < len(s); i++ {
+		cols.advanceByte(s[i])
+	}
+}
+
+func (cols *columns) advanceByte(b byte) {
+	switch {
+	case b == '\n':
+		cols.colm = 1
+	case b == '\r':
+		// like skipWhiteSpace, it is part of the newline
+	case cols.unit == token.BYTES:
+		cols.colm++
+	case isContinuation(b):
+	case b >= 0xF0 && cols.unit == token.UTF16_CODE_UNITS:
+		// outside of the basic multilingual plane, takes a surrogate pair
+		cols.colm += 2
+	default:
+		cols.colm++
+	}
+}
+
+func isContinuation(b byte) bool {
+	return b&0xC0 == 0x80
+}
@@ -0,0 +1,251 @@
+package lexer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/token"
+)
+
+var json5Keywords = map[string]token.TokenType{
+	"true":  token.TRUE,
+	"false": token.FALSE,
+	"null":  token.NULL,
+}
+
+// strings, numbers and identifiers which are valid only in json5. on failure the
+// caller falls back to the json rules
+func tryGetJSON5Token(input string, ind int, row int, colm int) (token.Token, bool, int, int, int) {
+	b := input[ind]
+	switch {
+	case b == '"' || b == '\'':
+		tok, ok, ind, row, colm := tryGetJSON5String(input, ind, row, colm)
+		if ok {
+			return tok, ok, ind, row, colm
+		}
+		tok, ind, row, colm = getUndefined(input, ind, row, colm)
+		return tok, true, ind, row, colm
+	case b == '+' || b == '-' || b == '.' || isDigit(rune(b)):
+		return tryGetJSON5Number(input, ind, row, colm)
+	default:
+		tok, ok, ind, row, colm := tryGetKeyword(input, ind, row, colm)
+		if ok {
+			return tok, ok, ind, row, colm
+		}
+		tok, ok, ind, row, colm = tryGetJSON5Number(input, ind, row, colm)
+		if ok {
+			return tok, ok, ind, row, colm
+		}
+
+		tok, ok, ind, row, colm = tryGetIdentifier(input, ind, row, colm)
+		// tryGetKeyword doesn't take the json5 whitespace as a delimiter
+		if typ, keyword := json5Keywords[tok.Literal]; ok && keyword {
+			tok.Type = typ
+		}
+		return tok, ok, ind, row, colm
+	}
+}
+
+// single or double quoted string. escapes can be any char and a backslash
+// before a newline continues the string on the next line
+func tryGetJSON5String(input string, ind int, row int, colm int) (token.Token, bool, int, int, int) {
+	quote := rune(input[ind])
+	typ := token.STRING_LITERAL
+	if quote == '\'' {
+		typ = token.SINGLE_STRING_LITERAL
+	}
+
+	startRow, startColm := row, colm
+	n := len(input)
+	i := ind + 1
+	colm++
+	for i < n {
+		r, size := utf8.DecodeRuneInString(input[i:])
+		switch r {
+		case quote:
+			return token.New(typ, input[ind+1:i], startRow, startColm), true, i + 1, row, colm + 1
+		case '\\':
+			offset, newLine, ok := tryGetJSON5Escape(input, i)
+			if !ok {
+				return token.Token{}, false, ind, startRow, startColm
+			}
+			i += offset
+			if newLine {
+				row++
+				colm = 1
+			} else {
+				colm += offset
+			}
+		case '\n', '\r':
+			return token.Token{}, false, ind, startRow, startColm
+		default:
+			i += size
+			colm += size
+		}
+	}
+
+	// not closed before the end of the input
+	return token.Token{}, false, ind, startRow, startColm
+}
+
+// returns the length of the escape and if it is a line continuation
+func tryGetJSON5Escape(input string, ind int) (int, bool, bool) {
+	rest := input[ind:]
+	if len(rest) < 2 || rest[0] != '\\' {
+		return 0, false, false
+	}
+
+	switch rest[1] {
+	case '\n':
+		return 2, true, true
+	case '\r':
+		if len(rest) > 2 && rest[2] == '\n' {
+			return 3, true, true
+		}
+		return 2, true, true
+	case 'x':
+		if len(rest) < 4 || !isHex(rune(rest[2])) || !isHex(rune(rest[3])) {
+			return 0, false, false
+		}
+		return 4, false, true
+	case 'u':
+		offset, ok := tryGetEscape(input, ind)
+		return offset, false, ok
+	case '0':
+		if len(rest) > 2 && isDigit(rune(rest[2])) {
+			return 0, false, false
+		}
+		return 2, false, true
+	case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return 0, false, false
+	}
+
+	// line and paragraph separators continue the line too, but like the columns
+	// and the diagnostics the rows count only '\n'
+	_, size := utf8.DecodeRuneInString(rest[1:])
+	return 1 + size, false, true
+}
+
+// json numbers plus hex, leading '+', leading or trailing decimal point, Infinity and NaN
+func tryGetJSON5Number(input string, ind int, row int, colm int) (token.Token, bool, int, int, int) {
+	n := len(input)
+	i := ind
+	if input[i] == '+' || input[i] == '-' {
+		i++
+	}
+
+	rest := input[i:]
+	switch {
+	case strings.HasPrefix(rest, "Infinity"):
+		i += len("Infinity")
+	case strings.HasPrefix(rest, "NaN"):
+		i += len("NaN")
+	case strings.HasPrefix(rest, "0x") || strings.HasPrefix(rest, "0X"):
+		i += 2
+		start := i
+		for i < n && isHex(rune(input[i])) {
+			i++
+		}
+		if i == start {
+			return token.Token{}, false, ind, row, colm
+		}
+	default:
+		digits := 0
+		if i < n && input[i] == '0' {
+			i++
+			digits++
+		} else {
+			for i < n && isDigit(rune(input[i])) {
+				i++
+				digits++
+			}
+		}
+
+		if i < n && input[i] == '.' {
+			i++
+			for i < n && isDigit(rune(input[i])) {
+				i++
+				digits++
+			}
+		}
+
+		if digits == 0 {
+			return token.Token{}, false, ind, row, colm
+		}
+
+		if i < n && (input[i] == 'e' || input[i] == 'E') {
+			i++
+			if i < n && (input[i] == '+' || input[i] == '-') {
+				i++
+			}
+			start := i
+			for i < n && isDigit(rune(input[i])) {
+				i++
+			}
+			if i == start {
+				return token.Token{}, false, ind, row, colm
+			}
+		}
+	}
+
+	if i < n && !isJSON5DelimAt(input, i) {
+		return token.Token{}, false, ind, row, colm
+	}
+
+	return token.New(token.NUMBER_LITERAL, input[ind:i], row, colm), true, i, row, colm + i - ind
+}
+
+// unquoted object keys, the escapes are kept as they are
+func tryGetIdentifier(input string, ind int, row int, colm int) (token.Token, bool, int, int, int) {
+	n := len(input)
+	i := ind
+	for i < n {
+		r, size := utf8.DecodeRuneInString(input[i:])
+		if r == '\\' {
+			offset, ok := tryGetEscape(input, i)
+			if !ok || input[i+1] != 'u' {
+				return token.Token{}, false, ind, row, colm
+			}
+			i += offset
+			continue
+		}
+
+		if !isIdentifierStart(r) && (i == ind || !isIdentifierPart(r)) {
+			break
+		}
+		i += size
+	}
+
+	if i == ind || i < n && !isJSON5DelimAt(input, i) {
+		return token.Token{}, false, ind, row, colm
+	}
+
+	return token.New(token.IDENTIFIER, input[ind:i], row, colm), true, i, row, colm + i - ind
+}
+
+func isIdentifierStart(r rune) bool {
+	return r == '$' || r == '_' || unicode.IsLetter(r) || unicode.Is(unicode.Nl, r)
+}
+
+func isIdentifierPart(r rune) bool {
+	return isIdentifierStart(r) || unicode.In(r, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc) ||
+		r == '\u200c' || r == '\u200d'
+}
+
+func isJSON5Delim(b rune) bool {
+	return isDelim(b) || b == '\''
+}
+
+// the delimiter can be json5 whitespace of more than one byte
+func isJSON5DelimAt(input string, i int) bool {
+	r, _ := utf8.DecodeRuneInString(input[i:])
+	return isJSON5Delim(r) || isJSON5Space(r)
+}
+
+// whitespace of json5 which json doesn't allow: vertical tab, form feed,
+// byte order mark, line and paragraph separators and the space separators
+func isJSON5Space(r rune) bool {
+	return r == '\v' || r == '\f' || r == '\ufeff' || r == '\u2028' || r == '\u2029' ||
+		r != ' ' && unicode.Is(unicode.Zs, r)
+}
@@ -0,0 +1,202 @@
+package lexer
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/token"
+)
+
+func TestTryGetJSON5Number(t *testing.T) {
+	valid := []string{"0", "-12", "+1", ".5", "5.", "-.5e-3", "5.E+3", "0x1F", "-0XaB", "Infinity", "+Infinity", "-NaN"}
+	for _, input := range valid {
+		tok, ok, ind, _, colm := tryGetJSON5Number(input+",", 0, 1, 1)
+
+		if !ok {
+			t.Errorf("Couldn`t get number token from %s", input)
+			continue
+		}
+
+		if tok.Type != token.NUMBER_LITERAL || tok.Literal != input {
+			t.Errorf("tok was expected to be number %s, but got %v", input, tok)
+		}
+
+		if ind != len(input) || colm != ind+1 {
+			t.Errorf("Wrong pointers for %s, got ind %d, colm %d", input, ind, colm)
+		}
+	}
+
+	invalid := []string{".", "+", "01", "0x", "1e", "Infinit", "1a", "0xG"}
+	for _, input := range invalid {
+		_, ok, ind, row, colm := tryGetJSON5Number(input, 0, 1, 1)
+
+		if ok {
+			t.Errorf("Failed to discard invalid number %s", input)
+		}
+
+		if ind != 0 || row != 1 || colm != 1 {
+			t.Errorf("Pointers have moved for %s", input)
+		}
+	}
+}
+
+func TestTryGetJSON5String(t *testing.T) {
+	input := `'it''s "fine" \' \x41 \
+next line'`
+
+	tok, ok, ind, row, colm := tryGetJSON5String(input, 4, 1, 5)
+
+	if !ok {
+		t.Fatal("Couldn`t get string token")
+	}
+
+	if tok.Type != token.SINGLE_STRING_LITERAL {
+		t.Fatalf("tok.Type was expected to be %d, but got %d", token.SINGLE_STRING_LITERAL, tok.Type)
+	}
+
+	literal := input[5 : len(input)-1]
+	if tok.Literal != literal {
+		t.Fatalf("tok.Literal was expected to be %s, but got %s", literal, tok.Literal)
+	}
+
+	if tok.Row != 1 || tok.Colm != 5 {
+		t.Fatalf("Token position was expected to be 1:5, but got %d:%d", tok.Row, tok.Colm)
+	}
+
+	if ind != len(input) || row != 2 || colm != 11 {
+		t.Fatalf("Wrong pointers, got ind %d, row %d, colm %d", ind, row, colm)
+	}
+}
+
+func TestTryGetJSON5StringInvalid(t *testing.T) {
+	invalid := []string{"'new\nline'", `'\1'`, `'\01'`, `'\xZ1'`}
+	for _, input := range invalid {
+		_, ok, ind, row, colm := tryGetJSON5String(input, 0, 1, 1)
+
+		if ok {
+			t.Errorf("Failed to discard invalid string %s", input)
+		}
+
+		if ind != 0 || row != 1 || colm != 1 {
+			t.Errorf("Pointers have moved for %s", input)
+		}
+	}
+}
+
+func TestTryGetIdentifier(t *testing.T) {
+	valid := []string{"key", "$_a1", "име", `\u0061b`}
+	for _, input := range valid {
+		tok, ok, ind, _, _ := tryGetIdentifier(input+":", 0, 1, 1)
+
+		if !ok {
+			t.Errorf("Couldn`t get identifier token from %s", input)
+			continue
+		}
+
+		if tok.Type != token.IDENTIFIER || tok.Literal != input {
+			t.Errorf("tok was expected to be identifier %s, but got %v", input, tok)
+		}
+
+		if ind != len(input) {
+			t.Errorf("Wrong value for ind, expected %d, but got %d", len(input), ind)
+		}
+	}
+
+	invalid := []string{"1key", "ke-y", `\n`, "-"}
+	for _, input := range invalid {
+		_, ok, _, _, _ := tryGetIdentifier(input, 0, 1, 1)
+
+		if ok {
+			t.Errorf("Failed to discard invalid identifier %s", input)
+		}
+	}
+}
+
+func TestLexerJSON5(t *testing.T) {
+	input := `{key: 'a, "b"', nan: NaN, hex: [0xFF,],}`
+	lex := NewWithDialect(strings.NewReader(input), token.JSON5)
+
+	expected := []token.Token{
+		{Type: token.LEFT_CURLY, Literal: "{", Row: 1, Colm: 1, Offset: 0, EndOffset: 1, EndRow: 1, EndColm: 2},
+		{Type: token.IDENTIFIER, Literal: "key", Row: 1, Colm: 2, Offset: 1, EndOffset: 4, EndRow: 1, EndColm: 5},
+		{Type: token.COLON, Literal: ":", Row: 1, Colm: 5, Offset: 4, EndOffset: 5, EndRow: 1, EndColm: 6},
+		{Type: token.SINGLE_STRING_LITERAL, Literal: `a, "b"`, Row: 1, Colm: 7, Offset: 6, EndOffset: 14, EndRow: 1, EndColm: 15},
+		{Type: token.SEMICOLON, Literal: ",", Row: 1, Colm: 15, Offset: 14, EndOffset: 15, EndRow: 1, EndColm: 16},
+		{Type: token.IDENTIFIER, Literal: "nan", Row: 1, Colm: 17, Offset: 16, EndOffset: 19, EndRow: 1, EndColm: 20},
+		{Type: token.COLON, Literal: ":", Row: 1, Colm: 20, Offset: 19, EndOffset: 20, EndRow: 1, EndColm: 21},
+		{Type: token.NUMBER_LITERAL, Literal: "NaN", Row: 1, Colm: 22, Offset: 21, EndOffset: 24, EndRow: 1, EndColm: 25},
+		{Type: token.SEMICOLON, Literal: ",", Row: 1, Colm: 25, Offset: 24, EndOffset: 25, EndRow: 1, EndColm: 26},
+		{Type: token.IDENTIFIER, Literal: "hex", Row: 1, Colm: 27, Offset: 26, EndOffset: 29, EndRow: 1, EndColm: 30},
+		{Type: token.COLON, Literal: ":", Row: 1, Colm: 30, Offset: 29, EndOffset: 30, EndRow: 1, EndColm: 31},
+		{Type: token.LEFT_SQUARE, Literal: "[", Row: 1, Colm: 32, Offset: 31, EndOffset: 32, EndRow: 1, EndColm: 33},
+		{Type: token.NUMBER_LITERAL, Literal: "0xFF", Row: 1, Colm: 33, Offset: 32, EndOffset: 36, EndRow: 1, EndColm: 37},
+		{Type: token.SEMICOLON, Literal: ",", Row: 1, Colm: 37, Offset: 36, EndOffset: 37, EndRow: 1, EndColm: 38},
+		{Type: token.RIGHT_SQUARE, Literal: "]", Row: 1, Colm: 38, Offset: 37, EndOffset: 38, EndRow: 1, EndColm: 39},
+		{Type: token.SEMICOLON, Literal: ",", Row: 1, Colm: 39, Offset: 38, EndOffset: 39, EndRow: 1, EndColm: 40},
+		{Type: token.RIGHT_CURLY, Literal: "}", Row: 1, Colm: 40, Offset: 39, EndOffset: 40, EndRow: 1, EndColm: 41},
+		{Type: token.EOF, Literal: "", Row: 1, Colm: 41, Offset: 40, EndOffset: 40, EndRow: 1, EndColm: 41},
+	}
+
+	ind := 0
+	for tok := range lex {
+		if ind >= len(expected) {
+			t.Fatalf("Unexpected token %v", tok)
+		}
+		if tok != expected[ind] {
+			t.Errorf("tok[%d] was expected to be %v, but got %v", ind, expected[ind], tok)
+		}
+		ind++
+	}
+
+	if ind != len(expected) {
+		t.Errorf("Expected len was %d, but got %d", len(expected), ind)
+	}
+}
+
+func TestLexerJSON5LineSeparator(t *testing.T) {
+	input := "['a\\\u2028b', tru]"
+
+	var tokens []token.Token
+	for tok := range NewWithDialect(strings.NewReader(input), token.JSON5) {
+		tokens = append(tokens, tok)
+	}
+
+	if len(tokens) != 6 || tokens[1].Literal != "a\\\u2028b" || tokens[1].EndRow != 1 {
+		t.Fatalf("Unexpected tokens %v", tokens)
+	}
+
+	// the separator is 3 bytes
+	if tokens[3].Row != 1 || tokens[3].Colm != 12 {
+		t.Fatalf("Expected tru at 1:12, but got %d:%d", tokens[3].Row, tokens[3].Colm)
+	}
+}
+
+func TestLexerJSON5WhiteSpace(t *testing.T) {
+	input := "\ufeff{a:\v1,\fb\u00a0:\u2028[true\u3000, Infinity\u2029,null\u00a0]}"
+	expected := []token.TokenType{
+		token.LEFT_CURLY, token.IDENTIFIER, token.COLON, token.NUMBER_LITERAL, token.SEMICOLON,
+		token.IDENTIFIER, token.COLON, token.LEFT_SQUARE, token.TRUE, token.SEMICOLON,
+		token.NUMBER_LITERAL, token.SEMICOLON, token.NULL, token.RIGHT_SQUARE, token.RIGHT_CURLY, token.EOF,
+	}
+
+	for _, r := range []io.Reader{strings.NewReader(input), iotest.OneByteReader(strings.NewReader(input))} {
+		var types []token.TokenType
+		for tok := range NewWithDialect(r, token.JSON5) {
+			types = append(types, tok.Type)
+		}
+
+		if !slices.Equal(types, expected) {
+			t.Errorf("Expected %v, but got %v", expected, types)
+		}
+	}
+
+	// json doesn't allow them
+	for tok := range New(strings.NewReader("[\v1]")) {
+		if tok.Type == token.NUMBER_LITERAL {
+			t.Fatalf("Expected \\v to be invalid in json, but got %v", tok)
+		}
+	}
+}
@@ -1,99 +1,147 @@
 package lexer
 
 import (
-	"bufio"
 	"io"
 	"iter"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/lastvoidtemplar/json_formatter/internal/token"
 )
 
 func New(r io.Reader) iter.Seq[token.Token] {
+	return NewWithDialect(r, token.JSON)
+}
+
+type Options struct {
+	Dialect token.Dialect
+	// unit of Colm and EndColm of the tokens
+	Columns token.ColumnUnit
+}
+
+func NewWithDialect(r io.Reader, dialect token.Dialect) iter.Seq[token.Token] {
+	return NewWithOptions(r, Options{Dialect: dialect})
+}
+
+func NewWithOptions(r io.Reader, opts Options) iter.Seq[token.Token] {
 	return func(yield func(token.Token) bool) {
-		row := 1
-		colm := 1
-
-		scanner := bufio.NewScanner(r)
-		scanner.Split(splitScannerFunc)
-
-		var lastToken token.Token
-		for scanner.Scan() {
-			input := scanner.Text()
-			ind := 0
-			var token token.Token
-
-			n := len(input)
-			for ind < n {
-				ind, row, colm = skipWhiteSpace(input, ind, row, colm)
-				token, ind, row, colm = getToken(input, ind, row, colm)
-				lastToken = token
-				if !yield(token) {
-					return
-				}
-				ind, row, colm = skipWhiteSpace(input, ind, row, colm)
-			}
+		lex(newSource(r), opts, yield)
+	}
+}
+
+// lexes the input in memory without copying it, the literals of the tokens
+// are substrings of b. b must not be modified while the tokens are in use
+func NewBytes(b []byte) iter.Seq[token.Token] {
+	return NewBytesWithOptions(b, Options{})
+}
 
+func NewBytesWithOptions(b []byte, opts Options) iter.Seq[token.Token] {
+	return func(yield func(token.Token) bool) {
+		lex(newBytesSource(b), opts, yield)
+	}
+}
+
+func lex(src *source, opts Options, yield func(token.Token) bool) {
+	dialect := opts.Dialect
+	row := 1
+	colm := 1
+	// the columns in the requested unit, colm counts bytes
+	cols := columns{unit: opts.Columns, colm: 1}
+
+	var lastToken token.Token
+	for {
+		row, colm = skipWhiteSpace(src, row, colm, &cols, dialect)
+		if !src.has(0) {
+			break
 		}
 
-		if err := scanner.Err(); err != nil {
-			yield(token.New(token.ERR, err.Error(), row, colm))
-			return
+		input := src.text(src.lexemeLen(dialect))
+		offset := src.offset()
+
+		var tok token.Token
+		var ind int
+		tok, ind, row, colm = getToken(input, 0, row, colm, dialect)
+		if !utf8.ValidString(input[:ind]) {
+			tok = invalidUTF8(tok, input[:ind])
 		}
 
-		if lastToken.Type != token.EOF {
-			yield(token.New(token.EOF, "", row, colm))
+		tok.Colm = cols.colm
+		cols.advance(input[:ind])
+		tok = setEnd(tok, offset, offset+ind, row, cols.colm)
+		src.advance(ind)
+
+		lastToken = tok
+		if !yield(tok) {
 			return
 		}
 	}
-}
 
-func splitScannerFunc(data []byte, atEOF bool) (advance int, token []byte, err error) {
-	if atEOF {
-		return 0, nil, nil
+	offset := src.offset()
+	if err := src.Err(); err != nil {
+		yield(setEnd(token.New(token.ERR, err.Error(), row, cols.colm), offset, offset, row, cols.colm))
+		return
 	}
 
-	quotes := 0
-	ind := 0
-	n := len(data)
-	for ; ind < n; ind++ {
-		b := data[ind]
-		switch b {
-		case ',':
-			if quotes%2 == 0 {
-				return ind + 1, data[:ind+1], nil
-			}
-		case '"':
-			if ind == 0 || data[ind-1] != '\\' {
-				quotes++
-			}
-		}
+	if lastToken.Type != token.EOF {
+		yield(setEnd(token.New(token.EOF, "", row, cols.colm), offset, offset, row, cols.colm))
 	}
+}
 
-	return ind, data, nil
+// keeps the position of the token, but not its type
+func invalidUTF8(tok token.Token, literal string) token.Token {
+	return token.New(token.INVALID_UTF8, literal, tok.Row, tok.Colm)
 }
 
-func skipWhiteSpace(input string, ind int, row int, colm int) (int, int, int) {
-	n := len(input)
-	for i := ind; i < n; i++ {
-		switch input[i] {
-		case ' ':
-			fallthrough
-		case '\t':
+func setEnd(tok token.Token, offset int, endOffset int, endRow int, endColm int) token.Token {
+	tok.Offset = offset
+	tok.EndOffset = endOffset
+	tok.EndRow = endRow
+	tok.EndColm = endColm
+	return tok
+}
+
+func skipWhiteSpace(src *source, row int, colm int, cols *columns, dialect token.Dialect) (int, int) {
+	for src.has(0) {
+		b := src.at(0)
+		switch b {
+		case ' ', '\t':
 			colm++
 		case '\r':
-			continue
 		case '\n':
 			colm = 1
 			row++
 		default:
-			return i, row, colm
+			n := 0
+			if dialect == token.JSON5 {
+				n = src.json5SpaceLen()
+			}
+			if n == 0 {
+				return row, colm
+			}
+
+			for i := range n {
+				cols.advanceByte(src.at(i))
+			}
+			colm += n
+			src.advance(n)
+			continue
 		}
+		cols.advanceByte(b)
+		src.advance(1)
 	}
-	return len(input), row, colm
+	return row, colm
 }
 
-func getToken(input string, ind int, row int, colm int) (token.Token, int, int, int) {
+func getToken(input string, ind int, row int, colm int, dialect token.Dialect) (token.Token, int, int, int) {
 	var tok token.Token
+	if dialect == token.JSON5 {
+		var ok bool
+		tok, ok, ind, row, colm = tryGetJSON5Token(input, ind, row, colm)
+		if ok {
+			return tok, ind, row, colm
+		}
+	}
+
 	switch input[ind] {
 	case 0:
 		return token.New(token.EOF, "", row, colm), ind + 1, row, colm + 1
@@ -116,6 +164,13 @@ func getToken(input string, ind int, row int, colm int) (token.Token, int, int,
 			return tok, ind, row, colm
 		}
 		return getUndefined(input, ind, row, colm)
+	case '/':
+		var ok bool
+		tok, ok, ind, row, colm = tryGetComment(input, ind, row, colm)
+		if ok {
+			return tok, ind, row, colm
+		}
+		return getUndefined(input, ind, row, colm)
 	default:
 		var ok bool
 		tok, ok, ind, row, colm = tryGetKeyword(input, ind, row, colm)
@@ -136,7 +191,6 @@ func tryGetString(input string, ind int, row int, colm int) (token.Token, bool,
 		return token.Token{}, false, ind, row, colm
 	}
 
-	n := len(input)
 	skip := 0
 	for i, b := range input[ind+1:] {
 		if skip > 0 {
@@ -160,7 +214,40 @@ func tryGetString(input string, ind int, row int, colm int) (token.Token, bool,
 		}
 
 	}
-	return token.New(token.ERR, "the buffer was to small to close a string", row, colm), true, n, row, colm + n - ind
+	// not closed before the end of the input
+	return token.Token{}, false, ind, row, colm
+}
+
+// line comments end before the newline, block comments can span multiple lines
+func tryGetComment(input string, ind int, row int, colm int) (token.Token, bool, int, int, int) {
+	rest := input[ind:]
+	if !strings.HasPrefix(rest, "//") && !strings.HasPrefix(rest, "/*") {
+		return token.Token{}, false, ind, row, colm
+	}
+
+	if rest[1] == '/' {
+		end := strings.IndexByte(rest, '\n')
+		if end == -1 {
+			end = len(rest)
+		}
+		end = len(strings.TrimRight(rest[:end], "\r"))
+		return token.New(token.LINE_COMMENT, rest[:end], row, colm), true, ind + end, row, colm + end
+	}
+
+	end := strings.Index(rest[2:], "*/")
+	if end == -1 {
+		return token.New(token.UNDEFINED, rest, row, colm), true, len(input), row, colm + len(rest)
+	}
+	end += 4
+
+	literal := rest[:end]
+	tok := token.New(token.BLOCK_COMMENT, literal, row, colm)
+
+	lines := strings.Count(literal, "\n")
+	if lines == 0 {
+		return tok, true, ind + end, row, colm + end
+	}
+	return tok, true, ind + end, row + lines, end - strings.LastIndexByte(literal, '\n')
 }
 
 func tryGetEscape(input string, ind int) (int, bool) {
@@ -191,6 +278,10 @@ func tryGetEscape(input string, ind int) (int, bool) {
 		}
 	}
 
+	// the input ended right after the backslash
+	if !escapeU {
+		return 0, false
+	}
 	return 6, true
 }
 
@@ -238,11 +329,12 @@ func tryGetNumber(input string, ind int, row int, colm int) (token.Token, bool,
 		i++
 	}
 
-	b := input[ind+i]
-	if ind+i == n || !isDigit(rune(b)) {
+	if ind+i == n || !isDigit(rune(input[ind+i])) {
 		return token.Token{}, false, ind, row, colm
 	}
 
+	b := input[ind+i]
+
 	if isDigitBiggerThanZero(b) {
 		for _, v := range input[ind+i:] {
 			if !isDigit(v) {
@@ -255,6 +347,9 @@ func tryGetNumber(input string, ind int, row int, colm int) (token.Token, bool,
 		}
 	} else {
 		i++
+		if ind+i == n {
+			return token.New(token.NUMBER_LITERAL, input[ind:], row, colm), true, n, row, colm + n - ind
+		}
 	}
 
 	b = input[ind+i]
@@ -322,12 +417,13 @@ func isDigit(b rune) bool {
 func isDelim(b rune) bool {
 	return b == ' ' || b == '\t' || b == '\r' || b == '\n' ||
 		b == '[' || b == ']' || b == '{' || b == '}' ||
-		b == ',' || b == ':' || b == '"'
+		b == ',' || b == ':' || b == '"' || b == '/'
 }
 
 func getUndefined(input string, ind int, row int, colm int) (token.Token, int, int, int) {
 	for i, b := range input[ind:] {
-		if i == 0 && b == '"' {
+		// the first char is part of the token even if it is a delimiter like '"' or '/'
+		if i == 0 {
 			continue
 		}
 		if isDelim(b) {
@@ -1,35 +1,17 @@
 package lexer
 
 import (
-	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/lastvoidtemplar/json_formatter/internal/token"
 )
 
-func TestScannerSplitFunc(t *testing.T) {
-	input := `"hello" : "world\"ddd",
-			"age": 11111`
-	scanner := bufio.NewScanner(strings.NewReader(input))
-	scanner.Split(splitScannerFunc)
-
-	expected := []string{
-		`"hello" : "world\"ddd",`,
-		"\n			\"age\": 11111",
-	}
-
-	ind := 0
-	for scanner.Scan() {
-		text := scanner.Text()
-		if text != expected[ind] {
-			t.Errorf("Text[%d] was expected to be %s(len = %d), but got %s(len = %d)",
-				ind, expected[ind], len(expected[ind]), text, len(text))
-		}
-		ind++
-	}
-}
-
 func TestTryGetEscapeWithout(t *testing.T) {
 	input := `//`
 
@@ -139,33 +121,19 @@ func TestTryGetStringValid(t *testing.T) {
 	}
 }
 
-func TestTryGetStringValidSmallBuffer(t *testing.T) {
+func TestTryGetStringUnclosed(t *testing.T) {
 	input := `"hello world`
 	ind, row, colm := 0, 1, 1
 
-	var tok token.Token
 	var ok bool
+	_, ok, ind, row, colm = tryGetString(input, ind, row, colm)
 
-	tok, ok, ind, row, colm = tryGetString(input, ind, row, colm)
-
-	if !ok {
-		t.Fatal("Failed to get the error token")
-	}
-
-	if tok.Type != token.ERR {
-		t.Fatalf("tok.Type was expected to be %d, but got %d", token.STRING_LITERAL, tok.Type)
-	}
-
-	if ind != len(input) {
-		t.Fatalf("Wrong value for ind, expected %d, but got %d", len(input), ind)
-	}
-
-	if ind+1 != colm {
-		t.Fatalf("Colm was expected to be 1 more then ind (%d), but got %d", ind+1, colm)
+	if ok {
+		t.Fatal("Unclosed string was accepted")
 	}
 
-	if row != 1 {
-		t.Fatalf("Row was expected to remain 1, but got %d", row)
+	if ind != 0 || row != 1 || colm != 1 {
+		t.Fatalf("Pointers have moved, got ind %d, row %d, colm %d", ind, row, colm)
 	}
 }
 
@@ -749,3 +717,413 @@ func TestLexer(t *testing.T) {
 		t.Errorf("Expected len was %d, but got %d", len(expected), ind)
 	}
 }
+
+func TestTryGetCommentLine(t *testing.T) {
+	input := "// don't, split \"here\"\r\n1"
+	ind, row, colm := 0, 1, 1
+
+	var tok token.Token
+	var ok bool
+	tok, ok, ind, row, colm = tryGetComment(input, ind, row, colm)
+
+	if !ok {
+		t.Fatal("Couldn`t get line comment token")
+	}
+
+	if tok.Type != token.LINE_COMMENT {
+		t.Fatalf("tok.Type was expected to be %d, but got %d", token.LINE_COMMENT, tok.Type)
+	}
+
+	if tok.Literal != `// don't, split "here"` {
+		t.Fatalf("tok.Literal was expected to be %s, but got %s", `// don't, split "here"`, tok.Literal)
+	}
+
+	if ind != len(tok.Literal) || colm != ind+1 || row != 1 {
+		t.Fatalf("Wrong pointers, got ind %d, row %d, colm %d", ind, row, colm)
+	}
+}
+
+func TestTryGetCommentBlock(t *testing.T) {
+	input := "/* first\n  second */ 1"
+	ind, row, colm := 0, 1, 1
+
+	var tok token.Token
+	var ok bool
+	tok, ok, ind, row, colm = tryGetComment(input, ind, row, colm)
+
+	if !ok {
+		t.Fatal("Couldn`t get block comment token")
+	}
+
+	if tok.Type != token.BLOCK_COMMENT {
+		t.Fatalf("tok.Type was expected to be %d, but got %d", token.BLOCK_COMMENT, tok.Type)
+	}
+
+	if tok.Literal != input[:len(input)-2] {
+		t.Fatalf("tok.Literal was expected to be %s, but got %s", input[:len(input)-2], tok.Literal)
+	}
+
+	if ind != len(input)-2 || row != 2 || colm != 12 {
+		t.Fatalf("Wrong pointers, got ind %d, row %d, colm %d", ind, row, colm)
+	}
+}
+
+func TestTryGetCommentInvalid(t *testing.T) {
+	input := "/ 1"
+	ind, row, colm := 0, 1, 1
+
+	var ok bool
+	_, ok, ind, row, colm = tryGetComment(input, ind, row, colm)
+
+	if ok {
+		t.Fatal("Failed to discard invalid comment")
+	}
+
+	if ind != 0 || row != 1 || colm != 1 {
+		t.Fatal("Pointers have moved")
+	}
+}
+
+func TestLexerComments(t *testing.T) {
+	input := `// head, "quoted
+[1, /* a,
+b */ true// tail
+]`
+	lex := New(strings.NewReader(input))
+
+	expected := []token.Token{
+		{Type: token.LINE_COMMENT, Literal: `// head, "quoted`, Row: 1, Colm: 1, Offset: 0, EndOffset: 16, EndRow: 1, EndColm: 17},
+		{Type: token.LEFT_SQUARE, Literal: "[", Row: 2, Colm: 1, Offset: 17, EndOffset: 18, EndRow: 2, EndColm: 2},
+		{Type: token.NUMBER_LITERAL, Literal: "1", Row: 2, Colm: 2, Offset: 18, EndOffset: 19, EndRow: 2, EndColm: 3},
+		{Type: token.SEMICOLON, Literal: ",", Row: 2, Colm: 3, Offset: 19, EndOffset: 20, EndRow: 2, EndColm: 4},
+		{Type: token.BLOCK_COMMENT, Literal: "/* a,\nb */", Row: 2, Colm: 5, Offset: 21, EndOffset: 31, EndRow: 3, EndColm: 5},
+		{Type: token.TRUE, Literal: "true", Row: 3, Colm: 6, Offset: 32, EndOffset: 36, EndRow: 3, EndColm: 10},
+		{Type: token.LINE_COMMENT, Literal: "// tail", Row: 3, Colm: 10, Offset: 36, EndOffset: 43, EndRow: 3, EndColm: 17},
+		{Type: token.RIGHT_SQUARE, Literal: "]", Row: 4, Colm: 1, Offset: 44, EndOffset: 45, EndRow: 4, EndColm: 2},
+		{Type: token.EOF, Literal: "", Row: 4, Colm: 2, Offset: 45, EndOffset: 45, EndRow: 4, EndColm: 2},
+	}
+
+	ind := 0
+	for tok := range lex {
+		if ind >= len(expected) {
+			t.Fatalf("Unexpected token %v", tok)
+		}
+		exp := expected[ind]
+		if tok != exp {
+			t.Errorf("tok[%d] was expected to be %v, but got %v", ind, exp, tok)
+		}
+		ind++
+	}
+
+	if ind != len(expected) {
+		t.Errorf("Expected len was %d, but got %d", len(expected), ind)
+	}
+}
+
+func TestLexerOffsets(t *testing.T) {
+	input := "[\"é\",\r\n 1]"
+	lex := New(strings.NewReader(input))
+
+	expected := []struct {
+		offset    int
+		endOffset int
+		endRow    int
+		endColm   int
+	}{
+		{0, 1, 1, 2},
+		{1, 5, 1, 6},
+		{5, 6, 1, 7},
+		{9, 10, 2, 3},
+		{10, 11, 2, 4},
+		{11, 11, 2, 4},
+	}
+
+	ind := 0
+	for tok := range lex {
+		if ind >= len(expected) {
+			t.Fatalf("Unexpected token %v", tok)
+		}
+		exp := expected[ind]
+		if tok.Offset != exp.offset || tok.EndOffset != exp.endOffset || tok.EndRow != exp.endRow || tok.EndColm != exp.endColm {
+			t.Errorf("tok[%d] was expected to span %d-%d and end at %d:%d, but got %d-%d and %d:%d", ind,
+				exp.offset, exp.endOffset, exp.endRow, exp.endColm, tok.Offset, tok.EndOffset, tok.EndRow, tok.EndColm)
+		}
+		if tok.Type != token.EOF && input[tok.Offset:tok.EndOffset] == "" {
+			t.Errorf("tok[%d] has empty span", ind)
+		}
+		ind++
+	}
+
+	if ind != len(expected) {
+		t.Errorf("Expected len was %d, but got %d", len(expected), ind)
+	}
+}
+
+func TestLexerColumnUnits(t *testing.T) {
+	input := "[\"é😀\", 1,\n\"a\"]"
+
+	tests := []struct {
+		unit     token.ColumnUnit
+		expected [][2]int
+	}{
+		{token.BYTES, [][2]int{{1, 2}, {2, 10}, {10, 11}, {12, 13}, {13, 14}, {1, 4}, {4, 5}, {5, 5}}},
+		{token.CODE_POINTS, [][2]int{{1, 2}, {2, 6}, {6, 7}, {8, 9}, {9, 10}, {1, 4}, {4, 5}, {5, 5}}},
+		{token.UTF16_CODE_UNITS, [][2]int{{1, 2}, {2, 7}, {7, 8}, {9, 10}, {10, 11}, {1, 4}, {4, 5}, {5, 5}}},
+	}
+
+	for _, test := range tests {
+		ind := 0
+		for tok := range NewWithOptions(strings.NewReader(input), Options{Columns: test.unit}) {
+			if ind >= len(test.expected) {
+				t.Fatalf("Unexpected token %v", tok)
+			}
+			exp := test.expected[ind]
+			if tok.Colm != exp[0] || tok.EndColm != exp[1] {
+				t.Errorf("unit %d: tok[%d] was expected to take columns %d-%d, but got %d-%d", test.unit, ind, exp[0], exp[1], tok.Colm, tok.EndColm)
+			}
+			ind++
+		}
+
+		if ind != len(test.expected) {
+			t.Errorf("Expected len was %d, but got %d", len(test.expected), ind)
+		}
+	}
+}
+
+func TestLexerInvalidUTF8(t *testing.T) {
+	input := "[\"caf\xe9\", 1]"
+	lex := New(strings.NewReader(input))
+
+	expected := []token.Token{
+		{Type: token.LEFT_SQUARE, Literal: "[", Row: 1, Colm: 1, Offset: 0, EndOffset: 1, EndRow: 1, EndColm: 2},
+		{Type: token.INVALID_UTF8, Literal: "\"caf\xe9\"", Row: 1, Colm: 2, Offset: 1, EndOffset: 7, EndRow: 1, EndColm: 8},
+		{Type: token.SEMICOLON, Literal: ",", Row: 1, Colm: 8, Offset: 7, EndOffset: 8, EndRow: 1, EndColm: 9},
+		{Type: token.NUMBER_LITERAL, Literal: "1", Row: 1, Colm: 10, Offset: 9, EndOffset: 10, EndRow: 1, EndColm: 11},
+		{Type: token.RIGHT_SQUARE, Literal: "]", Row: 1, Colm: 11, Offset: 10, EndOffset: 11, EndRow: 1, EndColm: 12},
+		{Type: token.EOF, Literal: "", Row: 1, Colm: 12, Offset: 11, EndOffset: 11, EndRow: 1, EndColm: 12},
+	}
+
+	ind := 0
+	for tok := range lex {
+		if ind >= len(expected) {
+			t.Fatalf("Unexpected token %v", tok)
+		}
+		if tok != expected[ind] {
+			t.Errorf("tok[%d] was expected to be %v, but got %v", ind, expected[ind], tok)
+		}
+		ind++
+	}
+
+	if ind != len(expected) {
+		t.Errorf("Expected len was %d, but got %d", len(expected), ind)
+	}
+}
+
+func TestLexerSplitReads(t *testing.T) {
+	inputs := []string{
+		`{"a\\": "b\"c, d", "n": [-1.5e3, 0, true, null], "e": "é"}`,
+		"[1, // line, \"comment\"\n /* block,\n comment */ \"x\"]",
+		"[\nture , \n",
+		"[\"unclosed, 1]\n2",
+		"-",
+		"0",
+		`["a\`,
+	}
+
+	for _, input := range inputs {
+		var expected []token.Token
+		for tok := range New(strings.NewReader(input)) {
+			expected = append(expected, tok)
+		}
+
+		ind := 0
+		for tok := range New(iotest.OneByteReader(strings.NewReader(input))) {
+			if ind >= len(expected) {
+				t.Fatalf("Unexpected token %v for %q", tok, input)
+			}
+			if tok != expected[ind] {
+				t.Errorf("tok[%d] of %q was expected to be %v, but got %v", ind, input, expected[ind], tok)
+			}
+			ind++
+		}
+
+		if ind != len(expected) {
+			t.Errorf("Expected len for %q was %d, but got %d", input, len(expected), ind)
+		}
+	}
+}
+
+func TestLexerLongTokens(t *testing.T) {
+	str := strings.Repeat(`ab\"c,`, 50000)
+	num := strings.Repeat("7", 100000)
+	input := `["` + str + `", ` + num + `]`
+
+	expected := []token.Token{
+		{Type: token.LEFT_SQUARE, Literal: "["},
+		{Type: token.STRING_LITERAL, Literal: str},
+		{Type: token.SEMICOLON, Literal: ","},
+		{Type: token.NUMBER_LITERAL, Literal: num},
+		{Type: token.RIGHT_SQUARE, Literal: "]"},
+		{Type: token.EOF, Literal: ""},
+	}
+
+	ind := 0
+	for tok := range New(strings.NewReader(input)) {
+		if ind >= len(expected) {
+			t.Fatalf("Unexpected token %v", tok.Type)
+		}
+		if tok.Type != expected[ind].Type || tok.Literal != expected[ind].Literal {
+			t.Errorf("tok[%d] was expected to be of type %d with len %d, but got type %d with len %d",
+				ind, expected[ind].Type, len(expected[ind].Literal), tok.Type, len(tok.Literal))
+		}
+		ind++
+	}
+
+	if ind != len(expected) {
+		t.Errorf("Expected len was %d, but got %d", len(expected), ind)
+	}
+}
+
+func TestSourceBoundedBuffer(t *testing.T) {
+	input := "[" + strings.Repeat(`"value", 12345, `, 100000) + "null]"
+	src := newSource(strings.NewReader(input))
+
+	tokens := 0
+	for src.has(0) {
+		n := src.lexemeLen(token.JSON)
+		if n == 0 {
+			t.Fatal("Empty lexeme")
+		}
+		_, ind, _, _ := getToken(src.text(n), 0, 1, 1, token.JSON)
+		src.advance(ind)
+		skipWhiteSpace(src, 1, 1, &columns{}, token.JSON)
+		tokens++
+	}
+
+	if tokens != 400003 {
+		t.Errorf("Expected 400003 tokens, but got %d", tokens)
+	}
+	if len(src.buf) != initialBufSize {
+		t.Errorf("The buffer was expected to stay %d bytes, but got %d", initialBufSize, len(src.buf))
+	}
+}
+
+func TestNewBytes(t *testing.T) {
+	inputs := []string{
+		`{"a\\": "b\"c, d", "n": [-1.5e3, 0, true, null], "e": "é"}`,
+		"[1, // line\n /* block\n comment */ \"caf\xe9\", ture]",
+		`{unquoted: 'single', hex: 0x1F, "multi\` + "\n" + `line": +Infinity}`,
+		"",
+	}
+
+	for _, input := range inputs {
+		for _, dialect := range []token.Dialect{token.JSON, token.JSON5} {
+			var expected []token.Token
+			for tok := range NewWithDialect(strings.NewReader(input), dialect) {
+				expected = append(expected, tok)
+			}
+
+			ind := 0
+			for tok := range NewBytesWithOptions([]byte(input), Options{Dialect: dialect}) {
+				if ind >= len(expected) {
+					t.Fatalf("Unexpected token %v for %q", tok, input)
+				}
+				if tok != expected[ind] {
+					t.Errorf("tok[%d] of %q was expected to be %v, but got %v", ind, input, expected[ind], tok)
+				}
+				ind++
+			}
+
+			if ind != len(expected) {
+				t.Errorf("Expected len for %q was %d, but got %d", input, len(expected), ind)
+			}
+		}
+	}
+}
+
+func TestNewBytesAllocations(t *testing.T) {
+	input := []byte("[" + strings.Repeat(`{"key": "value", "n": -12.5e3, "ok": true}, `, 1000) + "null]")
+
+	allocs := testing.AllocsPerRun(10, func() {
+		for range NewBytes(input) {
+		}
+	})
+
+	// the source and the iterator, but nothing per token
+	if allocs > 5 {
+		t.Errorf("Lexing %d bytes made %.0f allocations", len(input), allocs)
+	}
+}
+
+func TestNewMapped(t *testing.T) {
+	input := `{"name": "mapped", "n": [1, 2.5], /* c */ "ok": true}`
+	path := filepath.Join(t.TempDir(), "input.json")
+	if err := os.WriteFile(path, []byte(input), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var expected []token.Token
+	for tok := range New(strings.NewReader(input)) {
+		expected = append(expected, tok)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	m, err := Mmap(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tokens []token.Token
+	for tok := range NewMapped(m, Options{}) {
+		tokens = append(tokens, tok)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the mapping is closed, but the literals are copies
+	if !slices.Equal(tokens, expected) {
+		t.Fatalf("Expected %v, but got %v", expected, tokens)
+	}
+}
+
+func TestMmapPipe(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	if _, err := Mmap(r); !errors.Is(err, ErrNotMappable) {
+		t.Fatalf("Expected ErrNotMappable for a pipe, but got %v", err)
+	}
+}
+
+func TestMmapEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.json")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	m, err := Mmap(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if len(m.Bytes()) != 0 {
+		t.Fatalf("Expected no bytes, but got %q", m.Bytes())
+	}
+}
@@ -0,0 +1,65 @@
+package lexer
+
+import (
+	"errors"
+	"iter"
+	"os"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/token"
+)
+
+var ErrNotMappable = errors.New("the file can't be memory-mapped")
+
+// a regular file mapped into memory. the bytes are read by the operating system
+// when they are used, so the input doesn't have to fit into the heap. the file
+// must not be truncated while it is mapped
+type Mapping struct {
+	data []byte
+}
+
+// pipes, terminals and other files which aren't regular return ErrNotMappable
+func Mmap(f *os.File) (*Mapping, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	size := info.Size()
+	if !info.Mode().IsRegular() || int64(int(size)) != size {
+		return nil, ErrNotMappable
+	}
+
+	// an empty mapping is invalid
+	if size == 0 {
+		return &Mapping{}, nil
+	}
+
+	data, err := mmap(f, int(size))
+	if err != nil {
+		return nil, err
+	}
+	return &Mapping{data: data}, nil
+}
+
+// the content of the file, valid until Close
+func (m *Mapping) Bytes() []byte {
+	return m.data
+}
+
+func (m *Mapping) Close() error {
+	if m.data == nil {
+		return nil
+	}
+
+	err := munmap(m.data)
+	m.data = nil
+	return err
+}
+
+// lexes the mapped file. unlike NewBytes the literals are copied, so the tokens
+// stay valid after the mapping is closed
+func NewMapped(m *Mapping, opts Options) iter.Seq[token.Token] {
+	return func(yield func(token.Token) bool) {
+		lex(newMappedSource(m.data), opts, yield)
+	}
+}
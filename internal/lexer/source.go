@@ -0,0 +1,229 @@
+package lexer
+
+import (
+	"io"
+	"unicode/utf8"
+	"unsafe"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/token"
+)
+
+const (
+	initialBufSize = 64 * 1024
+	// like bufio.Scanner, a reader which returns no data and no error so many
+	// times in a row is broken
+	maxEmptyReads = 100
+)
+
+// reads the input in chunks and keeps the bytes from the current token to the
+// end of the last read. the buffer grows only to fit a token, so the memory
+// depends on the longest token and not on the size of the input
+type source struct {
+	r   io.Reader
+	buf []byte
+	// the unread bytes are buf[pos:end]
+	pos int
+	end int
+	// offset of buf[0] in the input
+	base int
+	err  error
+	// the whole input when it is in memory, text returns substrings of it
+	str string
+}
+
+func newSource(r io.Reader) *source {
+	return &source{
+		r:   r,
+		buf: make([]byte, initialBufSize),
+	}
+}
+
+// the input is already read, so nothing is copied
+func newBytesSource(b []byte) *source {
+	return &source{
+		buf: b,
+		end: len(b),
+		err: io.EOF,
+		str: unsafe.String(unsafe.SliceData(b), len(b)),
+	}
+}
+
+// like newBytesSource, but text copies the bytes
+func newMappedSource(b []byte) *source {
+	return &source{
+		buf: b,
+		end: len(b),
+		err: io.EOF,
+	}
+}
+
+// reports if the byte i after the current one is available, reads more input if needed
+func (src *source) has(i int) bool {
+	for src.pos+i >= src.end {
+		if src.err != nil {
+			return false
+		}
+		src.fill()
+	}
+	return true
+}
+
+// the byte i after the current one, has(i) must be true
+func (src *source) at(i int) byte {
+	return src.buf[src.pos+i]
+}
+
+func (src *source) advance(n int) {
+	src.pos += n
+}
+
+// offset of the current byte in the input
+func (src *source) offset() int {
+	return src.base + src.pos
+}
+
+// the n bytes from the current one, has(n-1) must be true
+func (src *source) text(n int) string {
+	if src.str != "" {
+		return src.str[src.pos : src.pos+n]
+	}
+	return string(src.buf[src.pos : src.pos+n])
+}
+
+func (src *source) fill() {
+	if src.pos > 0 {
+		copy(src.buf, src.buf[src.pos:src.end])
+		src.end -= src.pos
+		src.base += src.pos
+		src.pos = 0
+	}
+
+	// grow before the buffer is full, otherwise a long token is read a few bytes at a time
+	if src.end > len(src.buf)/2 {
+		buf := make([]byte, 2*len(src.buf))
+		copy(buf, src.buf[:src.end])
+		src.buf = buf
+	}
+
+	for range maxEmptyReads {
+		n, err := src.r.Read(src.buf[src.end:])
+		src.end += n
+		if err != nil {
+			src.err = err
+			return
+		}
+		if n > 0 {
+			return
+		}
+	}
+	src.err = io.ErrNoProgress
+}
+
+// the read error or nil at the end of the input
+func (src *source) Err() error {
+	if src.err == io.EOF {
+		return nil
+	}
+	return src.err
+}
+
+// length of the input which getToken needs to see to lex the token at the
+// current byte: the whole token and the byte after it, or everything up to
+// the end of the input. getToken may take less, e.g. when a string is invalid
+func (src *source) lexemeLen(dialect token.Dialect) int {
+	i := 1
+	switch b := src.at(0); {
+	case b == 0 || b == ':' || b == ',' || b == '[' || b == ']' || b == '{' || b == '}':
+		return 1
+	case b == '"' || dialect == token.JSON5 && b == '\'':
+		i = src.stringLen(b, dialect)
+	case b == '/':
+		if n := src.commentLen(); n > 0 {
+			return n
+		}
+	}
+
+	// the rest is a keyword, number, identifier or undefined, all of them end
+	// at a delimiter. invalid strings become undefined tokens, so they end at
+	// a delimiter as well
+	for src.has(i) {
+		if isDelim(rune(src.at(i))) {
+			return i + 1
+		}
+		i++
+	}
+	return i
+}
+
+// length up to the closing quote, the newline which ends an invalid string or the end of the input
+func (src *source) stringLen(quote byte, dialect token.Dialect) int {
+	i := 1
+	for src.has(i) {
+		switch src.at(i) {
+		case quote:
+			return i + 1
+		case '\n', '\r':
+			return i
+		case '\\':
+			i += 2
+			// a json5 line continuation, the newline doesn't end the string
+			if dialect == token.JSON5 && src.has(i-1) && src.at(i-1) == '\r' && src.has(i) && src.at(i) == '\n' {
+				i++
+			}
+		default:
+			i++
+		}
+	}
+	return min(i, src.end-src.pos)
+}
+
+// length of the comment at the current byte or 0 if there is none. line
+// comments end before the newline, unclosed block comments take the rest of the input
+func (src *source) commentLen() int {
+	if !src.has(1) {
+		return 0
+	}
+
+	switch src.at(1) {
+	case '/':
+		i := 2
+		for src.has(i) && src.at(i) != '\n' {
+			i++
+		}
+		return i
+	case '*':
+		i := 2
+		for src.has(i + 1) {
+			if src.at(i) == '*' && src.at(i+1) == '/' {
+				return i + 2
+			}
+			i++
+		}
+		return src.end - src.pos
+	default:
+		return 0
+	}
+}
+
+// length of the whitespace at the current byte which only json5 allows, 0 if
+// there is none
+func (src *source) json5SpaceLen() int {
+	if b := src.at(0); b < utf8.RuneSelf {
+		if isJSON5Space(rune(b)) {
+			return 1
+		}
+		return 0
+	}
+
+	var buf [utf8.UTFMax]byte
+	n := 0
+	for n < len(buf) && src.has(n) {
+		buf[n] = src.at(n)
+		n++
+	}
+
+	if r, size := utf8.DecodeRune(buf[:n]); isJSON5Space(r) {
+		return size
+	}
+	return 0
+}
@@ -0,0 +1,236 @@
+package parser
+
+import (
+	"iter"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/ast"
+	"github.com/lastvoidtemplar/json_formatter/internal/token"
+)
+
+type EventType byte
+
+const (
+	START_OBJECT EventType = iota
+	KEY
+	END_OBJECT
+	START_ARRAY
+	VALUE
+	END_ARRAY
+	// comments are reported in the order of the input
+	COMMENT
+)
+
+type Event struct {
+	Type EventType
+	// the bracket, the key, the leaf value or the comment
+	Token token.Token
+	// the comment is the first token on its line
+	NewLine bool
+	// the comment belongs to the previous value like the trailing comments of
+	// the AST, it is before the comma or on the same line after it
+	Trailing bool
+}
+
+// reports the document as events without building the AST. the memory depends
+// on the nesting and on the keys of the open objects, which are needed to find
+// the duplicate keys. with AllowDuplicateKeys it depends only on the nesting.
+// the errors are the same as the errors of Parse without Recover. after an
+// error the iteration stops, the events before it are already reported.
+// Parse and Events can't be used on the same parser, TrailingCommas is only for Parse
+func (p *Parser) Events() iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		defer p.stopLexer()
+
+		e := &events{p: p, yield: yield}
+		if !e.value() || !e.emitComments(p.takeComments(), true) {
+			return
+		}
+
+		if p.currToken.Type != token.EOF {
+			e.fail(ErrExtraTokens, p.currToken, p.currToken.Literal)
+		} else if p.readErr != nil {
+			e.yield(Event{}, p.readErr)
+		}
+	}
+}
+
+type events struct {
+	p     *Parser
+	yield func(Event, error) bool
+}
+
+// the functions return false when the iteration has to stop
+
+func (e *events) emit(typ EventType, tok token.Token) bool {
+	return e.yield(Event{Type: typ, Token: tok}, nil)
+}
+
+// like Parse, the read error replaces the syntax error
+func (e *events) fail(err error, tok token.Token, actual string) bool {
+	if e.p.readErr != nil {
+		e.yield(Event{}, e.p.readErr)
+	} else {
+		e.yield(Event{}, newParserErr(err, tok, actual))
+	}
+	return false
+}
+
+// the comments before the current token
+func (e *events) comments() bool {
+	return e.emitComments(e.p.takeComments(), false)
+}
+
+func (e *events) emitComments(comments []ast.Comment, trailing bool) bool {
+	for _, comment := range comments {
+		event := Event{Type: COMMENT, Token: comment.Token, NewLine: comment.NewLine, Trailing: trailing}
+		if !e.yield(event, nil) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *events) value() bool {
+	p := e.p
+	if !e.comments() {
+		return false
+	}
+
+	tok := p.currToken
+	switch tok.Type {
+	case token.INVALID_UTF8:
+		return e.fail(ErrInvalidUTF8, tok, tok.Literal)
+	case token.UNDEFINED:
+		return e.fail(ErrInvalidType, tok, tok.Literal)
+	case token.LEFT_SQUARE:
+		return e.array()
+	case token.LEFT_CURLY:
+		return e.object()
+	}
+
+	if !isCurrLeaf(tok) {
+		return e.fail(ErrInvalidType, tok, tok.Literal)
+	}
+
+	p.NextToken()
+	return e.emit(VALUE, tok)
+}
+
+func (e *events) array() bool {
+	p := e.p
+	if !e.emit(START_ARRAY, p.currToken) {
+		return false
+	}
+	p.NextToken()
+
+	for {
+		if !e.comments() {
+			return false
+		}
+
+		switch p.currToken.Type {
+		case token.RIGHT_SQUARE:
+			tok := p.currToken
+			p.NextToken()
+			return e.emit(END_ARRAY, tok)
+		case token.EOF:
+			return e.fail(ErrMissingArrayClosingBracket, p.currToken, actual(p.currToken))
+		}
+
+		if !e.value() || !e.separator(token.RIGHT_SQUARE, ErrMissingArraySeparator) {
+			return false
+		}
+	}
+}
+
+func (e *events) object() bool {
+	p := e.p
+	if !e.emit(START_OBJECT, p.currToken) {
+		return false
+	}
+	p.NextToken()
+
+	var keys map[string]struct{}
+	if !p.opts.AllowDuplicateKeys {
+		keys = make(map[string]struct{})
+	}
+	for {
+		if !e.comments() {
+			return false
+		}
+
+		key := p.currToken
+		switch {
+		case key.Type == token.RIGHT_CURLY:
+			p.NextToken()
+			return e.emit(END_OBJECT, key)
+		case key.Type == token.EOF:
+			return e.fail(ErrMissingObjectClosingBracket, key, actual(key))
+		case key.Type == token.INVALID_UTF8:
+			return e.fail(ErrInvalidUTF8, key, key.Literal)
+		case !p.isKey(key):
+			return e.fail(ErrKeyNotString, key, key.Literal)
+		}
+
+		if !e.emit(KEY, key) {
+			return false
+		}
+		p.NextToken()
+
+		if !e.comments() {
+			return false
+		}
+		if p.currToken.Type != token.COLON {
+			return e.fail(ErrMissingKeyvalSeparator, p.currToken, p.currToken.Literal)
+		}
+		p.NextToken()
+
+		if !e.value() {
+			return false
+		}
+
+		// like Parse, the duplicate is found after its value
+		if keys != nil {
+			if _, ok := keys[key.Literal]; ok {
+				return e.fail(ErrDuplicateKeys, key, "")
+			}
+			keys[key.Literal] = struct{}{}
+		}
+
+		if !e.separator(token.RIGHT_CURLY, ErrMissingObjectSeparator) {
+			return false
+		}
+	}
+}
+
+// the comma after an element. the closing bracket and EOF are left for the caller
+func (e *events) separator(closing token.TokenType, errSeparator error) bool {
+	p := e.p
+
+	switch p.currToken.Type {
+	case closing:
+		return e.emitComments(p.takeSameLineComments(), true)
+	case token.EOF:
+		return true
+	case token.SEMICOLON:
+	default:
+		if !e.comments() {
+			return false
+		}
+		return e.fail(errSeparator, p.currToken, p.currToken.Literal)
+	}
+
+	if !e.emitComments(p.takeComments(), true) {
+		return false
+	}
+
+	p.NextToken()
+	if !e.emitComments(p.takeSameLineComments(), true) {
+		return false
+	}
+
+	if p.currToken.Type == closing && !p.allowsTrailingComma() {
+		return e.fail(ErrInvalidType, p.currToken, p.currToken.Literal)
+	}
+	return true
+}
@@ -0,0 +1,206 @@
+package parser
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/lexer"
+	"github.com/lastvoidtemplar/json_formatter/internal/token"
+)
+
+func collectEvents(input string, opts Options, t *testing.T) ([]Event, error) {
+	p, err := NewWithOptions(lexer.NewWithDialect(strings.NewReader(input), opts.Dialect), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []Event
+	for event, err := range p.Events() {
+		if err != nil {
+			return events, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func TestParserEvents(t *testing.T) {
+	input := `// head
+{"a": [1, true], /* b */ "b": {"c": null}, "d": []} // tail`
+
+	events, err := collectEvents(input, Options{}, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		typ     EventType
+		literal string
+	}{
+		{COMMENT, "// head"},
+		{START_OBJECT, "{"},
+		{KEY, "a"},
+		{START_ARRAY, "["},
+		{VALUE, "1"},
+		{VALUE, "true"},
+		{END_ARRAY, "]"},
+		{COMMENT, "/* b */"},
+		{KEY, "b"},
+		{START_OBJECT, "{"},
+		{KEY, "c"},
+		{VALUE, "null"},
+		{END_OBJECT, "}"},
+		{KEY, "d"},
+		{START_ARRAY, "["},
+		{END_ARRAY, "]"},
+		{END_OBJECT, "}"},
+		{COMMENT, "// tail"},
+	}
+
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, but got %d: %v", len(expected), len(events), events)
+	}
+
+	for i, event := range events {
+		if event.Type != expected[i].typ || event.Token.Literal != expected[i].literal {
+			t.Errorf("event[%d] was expected to be %d %q, but got %d %q",
+				i, expected[i].typ, expected[i].literal, event.Type, event.Token.Literal)
+		}
+	}
+
+	if !events[0].NewLine || events[len(events)-1].NewLine {
+		t.Errorf("Expected only the head comment on its own line")
+	}
+}
+
+func TestParserEventsErrors(t *testing.T) {
+	inputs := []string{
+		`[1 2]`,
+		`[1,]`,
+		`[1, ture]`,
+		`{"a" 1}`,
+		`{"a": 1 "b": 2}`,
+		`{"a": 1, "a": 2}`,
+		`{"a": 1, "a": ture}`,
+		`{a: 1}`,
+		`[[1, 2]`,
+		`{"a": {}`,
+		`[1] 2`,
+		"[\"caf\xe9\"]",
+		`[1}`,
+		`}`,
+	}
+
+	for _, input := range inputs {
+		_, err := collectEvents(input, Options{}, t)
+
+		p, _ := New(lexer.New(strings.NewReader(input)))
+		_, expected := p.Parse()
+
+		if err == nil || expected == nil || err.Error() != expected.Error() {
+			t.Errorf("Expected %v for %s, but got %v", expected, input, err)
+		}
+	}
+}
+
+func TestParserEventsTrailingCommas(t *testing.T) {
+	input := `{unquoted: ['a', 2,],}`
+	events, err := collectEvents(input, Options{Dialect: token.JSON5}, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 7 || events[1].Token.Type != token.IDENTIFIER {
+		t.Fatalf("Unexpected events %v", events)
+	}
+
+	_, err = collectEvents(`[1,]`, Options{AllowTrailingCommas: true}, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParserEventsStop(t *testing.T) {
+	p, err := New(lexer.New(strings.NewReader(`[1, 2, 3]`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+	for range p.Events() {
+		count++
+		if count == 2 {
+			break
+		}
+	}
+
+	if count != 2 {
+		t.Fatalf("Expected to stop after 2 events, but got %d", count)
+	}
+}
+
+func TestParserEventsDuplicateKeys(t *testing.T) {
+	events, err := collectEvents(`{"a": 1, "a": 2}`, Options{AllowDuplicateKeys: true}, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 6 || events[3].Token.Literal != "a" {
+		t.Fatalf("Unexpected events %v", events)
+	}
+}
+
+func TestParserEventsMemory(t *testing.T) {
+	allocs := func(keys int) float64 {
+		var b strings.Builder
+		b.WriteString("{")
+		for i := range keys {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(`"key": [1,]`)
+		}
+		b.WriteString("}")
+		input := []byte(b.String())
+
+		return testing.AllocsPerRun(10, func() {
+			p, err := NewWithOptions(lexer.NewBytes(input), Options{AllowDuplicateKeys: true, AllowTrailingCommas: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, err := range p.Events() {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+		})
+	}
+
+	// neither the keys nor the commas are kept
+	if small, large := allocs(10), allocs(10000); small != large {
+		t.Fatalf("Expected the same allocations for 10 and 10000 keys, but got %v and %v", small, large)
+	}
+}
+
+func TestParserEventsReadErr(t *testing.T) {
+	readErr := errors.New("broken pipe")
+	for _, input := range []string{"[1, 2", "[1, 2]"} {
+		p, err := New(lexer.New(io.MultiReader(strings.NewReader(input), iotest.ErrReader(readErr))))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var last error
+		for _, err := range p.Events() {
+			last = err
+		}
+
+		var parserErr *ParserError
+		if last == nil || last.Error() != readErr.Error() || errors.As(last, &parserErr) {
+			t.Errorf("Expected the read error for %q, but got %v", input, last)
+		}
+	}
+}
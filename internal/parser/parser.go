@@ -1,8 +1,8 @@
 package parser
 
 import (
-	"errors"
 	"iter"
+	"strings"
 
 	"github.com/lastvoidtemplar/json_formatter/internal/ast"
 	"github.com/lastvoidtemplar/json_formatter/internal/token"
@@ -11,71 +11,119 @@ import (
 type Parser struct {
 	currToken token.Token
 	peekToken token.Token
+	// comments between the previous token and currToken/peekToken
+	currComments []ast.Comment
+	peekComments []ast.Comment
+	// row on which the last pulled token ends
+	lastRow int
+	// end of the last consumed token
+	prevEnd token.Position
+	opts    Options
+	// positions of the accepted trailing commas
+	trailingCommas []token.Token
+	// errors from which the parser recovered
+	errs      ParserErrors
 	nextToken func() (token.Token, bool)
 	stopLexer func()
 	parserErr *ParserError
+	// the ERR token of the lexer, which is replaced by EOF
+	readErr error
 }
 
-var ErrEmptyLexer = errors.New("the lexer is empty")
+var ErrEmptyLexer = newCodeError("JF1010", "the lexer is empty")
 
 func New(lex iter.Seq[token.Token]) (*Parser, error) {
-	next, stop := iter.Pull(lex)
-
-	currToken, ok := next()
+	return NewWithDialect(lex, token.JSON)
+}
 
-	if !ok || currToken.Type == token.EOF {
-		return nil, ErrEmptyLexer
-	}
+type Options struct {
+	// the lexer must be created with the same dialect
+	Dialect token.Dialect
+	// accept a comma before ']' and '}'. json5 always accepts them
+	AllowTrailingCommas bool
+	// don't stop on the first error. Parse skips to the next ',', ']' or '}'
+	// and returns all errors as ParserErrors together with the partial AST
+	Recover bool
+	// accept the same key more than once in an object, so Events doesn't have to
+	// keep the keys of the open objects. Parse ignores it, the AST keeps one value per key
+	AllowDuplicateKeys bool
+}
 
-	peekToken, ok := next()
+func NewWithDialect(lex iter.Seq[token.Token], dialect token.Dialect) (*Parser, error) {
+	return NewWithOptions(lex, Options{Dialect: dialect})
+}
 
-	if !ok {
-		peekToken = newEOF()
-	}
+func NewWithOptions(lex iter.Seq[token.Token], opts Options) (*Parser, error) {
+	next, stop := iter.Pull(lex)
 
-	return &Parser{
+	p := &Parser{
+		opts:      opts,
 		nextToken: next,
 		stopLexer: stop,
-		currToken: currToken,
-		peekToken: peekToken,
 		parserErr: nil,
-	}, nil
+	}
+
+	p.peekToken, p.peekComments = p.pullToken()
+	p.NextToken()
+
+	if p.currToken.Type == token.EOF {
+		stop()
+		if p.readErr != nil {
+			return nil, p.readErr
+		}
+		return nil, ErrEmptyLexer
+	}
+
+	return p, nil
 }
 
 // if EOF token is not found, there is a bug in the lexer
-var ErrExtraTokens = errors.New("expected EOF")
+var ErrExtraTokens = newCodeError("JF1001", "expected EOF")
 
 // undefined token as a leaf
-var ErrInvalidType = errors.New("expected STRING, NUMBER, TRUE, FALSE, NULL, '[' or '{'")
+var ErrInvalidType = newCodeError("JF1002", "expected STRING, NUMBER, TRUE, FALSE, NULL, '[' or '{'")
 
 // did not found closing square bracket
-var ErrMissingArrayClosingBracket = errors.New("expected ']'")
+var ErrMissingArrayClosingBracket = newCodeError("JF1003", "expected ']'")
 
 // missing semicolon seperator in array element or missing closing square bracket
-var ErrMissingArraySeparator = errors.New("expected SEMICOLON or ']'")
+var ErrMissingArraySeparator = newCodeError("JF1004", "expected SEMICOLON or ']'")
 
 // did not found closing curly bracket
-var ErrMissingObjectClosingBracket = errors.New("expected '}'")
+var ErrMissingObjectClosingBracket = newCodeError("JF1005", "expected '}'")
 
 // missing semicolon seperator in object element or missing closing curly bracket
-var ErrMissingObjectSeparator = errors.New("expected SEMICOLON or '}'")
+var ErrMissingObjectSeparator = newCodeError("JF1006", "expected SEMICOLON or '}'")
 
 // found duplacate keys
-var ErrDuplicateKeys = errors.New("duplicate keys")
+var ErrDuplicateKeys = newCodeError("JF1007", "duplicate keys")
 
 // key must be string
-var ErrKeyNotString = errors.New("expected STRING")
+var ErrKeyNotString = newCodeError("JF1008", "expected STRING")
 
 // missing colon in keyval
-var ErrMissingKeyvalSeparator = errors.New("expectted COLON")
+var ErrMissingKeyvalSeparator = newCodeError("JF1009", "expectted COLON")
+
+// the token is not well-formed utf-8
+var ErrInvalidUTF8 = newCodeError("JF1011", "invalid UTF-8")
 
 func (p *Parser) Parse() (ast.Node, error) {
 	defer p.stopLexer()
 
 	root := p.parseNode()
 
+	// like when the input is read before the parsing, the read error replaces
+	// the syntax errors
+	if p.readErr != nil {
+		return nil, p.readErr
+	}
+
 	if p.parserErr != nil {
-		return nil, p.parserErr
+		if !p.opts.Recover {
+			return nil, p.parserErr
+		}
+		p.addErr(p.parserErr)
+		return nil, p.errs
 	}
 
 	if root == nil {
@@ -83,40 +131,71 @@ func (p *Parser) Parse() (ast.Node, error) {
 	}
 
 	if p.currToken.Type != token.EOF {
-		p.parserErr = newParserErr(ErrExtraTokens, p.currToken.Row, p.currToken.Colm, p.currToken.Literal)
-		return nil, p.parserErr
+		if !p.recoverFrom(newParserErr(ErrExtraTokens, p.currToken, p.currToken.Literal)) {
+			return nil, p.parserErr
+		}
+	} else {
+		comments := root.NodeComments()
+		comments.Trailing = append(comments.Trailing, p.takeComments()...)
+	}
+
+	if len(p.errs) > 0 {
+		return root, p.errs
 	}
 
 	return root, nil
 }
 
 func (p *Parser) parseNode() ast.Node {
+	leading := p.takeComments()
+	node := p.parseValue()
+
+	if p.parserErr != nil {
+		return node
+	}
+
+	comments := node.NodeComments()
+	comments.Leading = append(leading, comments.Leading...)
+	return node
+}
+
+func (p *Parser) parseValue() ast.Node {
 	if isCurrLeaf(p.currToken) {
 		tok := p.parserLeaf()
 		p.NextToken()
 		return tok
 	}
 
+	// after recovering the container can end without its closing bracket
 	if p.currToken.Type == token.LEFT_SQUARE {
 		tok := p.parseArray()
-		p.NextToken()
+		if p.currToken.Type == token.RIGHT_SQUARE {
+			p.NextToken()
+		}
 		return tok
 
 	}
 
 	if p.currToken.Type == token.LEFT_CURLY {
 		tok := p.parseObject()
-		p.NextToken()
+		if p.currToken.Type == token.RIGHT_CURLY {
+			p.NextToken()
+		}
 		return tok
 	}
 
-	p.parserErr = newParserErr(ErrInvalidType, p.currToken.Row, p.currToken.Colm, p.currToken.Literal)
+	p.parserErr = newParserErr(ErrInvalidType, p.currToken, p.currToken.Literal)
 	return nil
 }
 
 func (p *Parser) parserLeaf() ast.LeafNode {
+	if p.currToken.Type == token.INVALID_UTF8 {
+		p.parserErr = newParserErr(ErrInvalidUTF8, p.currToken, p.currToken.Literal)
+		return nil
+	}
+
 	if p.currToken.Type == token.UNDEFINED {
-		p.parserErr = newParserErr(ErrInvalidType, p.currToken.Row, p.currToken.Colm, p.currToken.Literal)
+		p.parserErr = newParserErr(ErrInvalidType, p.currToken, p.currToken.Literal)
 		return nil
 	}
 
@@ -125,20 +204,30 @@ func (p *Parser) parserLeaf() ast.LeafNode {
 
 func (p *Parser) parseArray() *ast.ArrayNode {
 	arrNode := ast.NewArrayNode()
+	arrNode.Start = p.currToken.Start()
 
 	p.NextToken()
 
-	if p.currToken.Type == token.EOF {
-		p.parserErr = newParserErr(ErrMissingArrayClosingBracket, p.currToken.Row, p.currToken.Colm, "EOF")
-		return nil
-	}
-
 	for p.currToken.Type != token.RIGHT_SQUARE {
 
+		if p.currToken.Type == token.EOF || p.opts.Recover && p.currToken.Type == token.RIGHT_CURLY {
+			if !p.recoverFrom(newParserErr(ErrMissingArrayClosingBracket, p.currToken, actual(p.currToken))) {
+				return nil
+			}
+			arrNode.End = p.prevEnd
+			return arrNode
+		}
+
 		node := p.parseNode()
 
 		if p.parserErr != nil {
-			return nil
+			if !p.opts.Recover {
+				return nil
+			}
+			p.addErr(p.parserErr)
+			p.parserErr = nil
+			p.skipElement()
+			continue
 		}
 
 		if node == nil {
@@ -147,44 +236,70 @@ func (p *Parser) parseArray() *ast.ArrayNode {
 		arrNode.Add(node)
 
 		if p.currToken.Type == token.EOF {
-			p.parserErr = newParserErr(ErrMissingArrayClosingBracket, p.currToken.Row, p.currToken.Colm, "EOF")
-			return nil
+			continue
 		}
 
 		if p.currToken.Type != token.SEMICOLON && p.currToken.Type != token.RIGHT_SQUARE {
-			p.parserErr = newParserErr(ErrMissingArraySeparator, p.currToken.Row, p.currToken.Colm, p.currToken.Literal)
-			return nil
+			if !p.recoverFrom(newParserErr(ErrMissingArraySeparator, p.currToken, p.currToken.Literal)) {
+				return nil
+			}
+			// continue like there was a comma, unless the next token is garbage
+			if !p.startsValue(p.currToken) {
+				p.skipElement()
+			}
+			continue
 		}
 
 		if p.currToken.Type == token.SEMICOLON {
+			p.attachTrailing(node, p.takeComments())
+			comma := p.currToken
 			p.NextToken()
 
 			if p.currToken.Type == token.RIGHT_SQUARE {
-				p.parserErr = newParserErr(ErrInvalidType, p.currToken.Row, p.currToken.Colm, p.currToken.Literal)
-				return nil
+				if !p.allowsTrailingComma() {
+					if !p.recoverFrom(newParserErr(ErrInvalidType, p.currToken, p.currToken.Literal)) {
+						return nil
+					}
+				} else {
+					p.trailingCommas = append(p.trailingCommas, comma)
+				}
 			}
 		}
+
+		p.attachTrailing(node, p.takeSameLineComments())
 	}
 
+	arrNode.Dangling = p.takeComments()
+	arrNode.End = p.currToken.End()
 	return arrNode
 }
 
 func (p *Parser) parseObject() *ast.ObjectNode {
 	objNode := ast.NewObjectNode()
+	objNode.Start = p.currToken.Start()
 
 	p.NextToken()
 
-	if p.currToken.Type == token.EOF {
-		p.parserErr = newParserErr(ErrMissingObjectClosingBracket, p.currToken.Row, p.currToken.Colm, "EOF")
-		return nil
-	}
-
 	for p.currToken.Type != token.RIGHT_CURLY {
 
+		if p.currToken.Type == token.EOF || p.opts.Recover && p.currToken.Type == token.RIGHT_SQUARE {
+			if !p.recoverFrom(newParserErr(ErrMissingObjectClosingBracket, p.currToken, actual(p.currToken))) {
+				return nil
+			}
+			objNode.End = p.prevEnd
+			return objNode
+		}
+
 		node := p.parseKeyVal()
 
 		if p.parserErr != nil {
-			return nil
+			if !p.opts.Recover {
+				return nil
+			}
+			p.addErr(p.parserErr)
+			p.parserErr = nil
+			p.skipElement()
+			continue
 		}
 
 		if node == nil {
@@ -194,78 +309,234 @@ func (p *Parser) parseObject() *ast.ObjectNode {
 		ok := objNode.Add(node)
 
 		if !ok {
-			p.parserErr = newParserErr(ErrDuplicateKeys, node.Key.Row, node.Key.Colm, "")
-			return nil
+			if !p.recoverFrom(newParserErr(ErrDuplicateKeys, node.Key, "")) {
+				return nil
+			}
 		}
 
 		if p.currToken.Type == token.EOF {
-			p.parserErr = newParserErr(ErrMissingObjectClosingBracket, p.currToken.Row, p.currToken.Colm, "EOF")
-			return nil
+			continue
 		}
 
 		if p.currToken.Type != token.SEMICOLON && p.currToken.Type != token.RIGHT_CURLY {
-			p.parserErr = newParserErr(ErrMissingObjectSeparator, p.currToken.Row, p.currToken.Colm, p.currToken.Literal)
-			return nil
+			if !p.recoverFrom(newParserErr(ErrMissingObjectSeparator, p.currToken, p.currToken.Literal)) {
+				return nil
+			}
+			// continue like there was a comma, unless the next token is garbage
+			if !p.isKey(p.currToken) {
+				p.skipElement()
+			}
+			continue
 		}
 
 		if p.currToken.Type == token.SEMICOLON {
+			p.attachTrailing(node, p.takeComments())
+			comma := p.currToken
 			p.NextToken()
 
 			if p.currToken.Type == token.RIGHT_CURLY {
-				p.parserErr = newParserErr(ErrInvalidType, p.currToken.Row, p.currToken.Colm, p.currToken.Literal)
-				return nil
+				if !p.allowsTrailingComma() {
+					if !p.recoverFrom(newParserErr(ErrInvalidType, p.currToken, p.currToken.Literal)) {
+						return nil
+					}
+				} else {
+					p.trailingCommas = append(p.trailingCommas, comma)
+				}
 			}
 		}
+
+		p.attachTrailing(node, p.takeSameLineComments())
 	}
 
+	objNode.Dangling = p.takeComments()
+	objNode.End = p.currToken.End()
 	return objNode
 }
 
 func (p *Parser) parseKeyVal() *ast.KeyValNode {
-	if p.currToken.Type != token.STRING_LITERAL {
-		p.parserErr = newParserErr(ErrKeyNotString, p.currToken.Row, p.currToken.Colm, p.currToken.Literal)
+	if p.currToken.Type == token.INVALID_UTF8 {
+		p.parserErr = newParserErr(ErrInvalidUTF8, p.currToken, p.currToken.Literal)
 		return nil
 	}
 
+	if !p.isKey(p.currToken) {
+		p.parserErr = newParserErr(ErrKeyNotString, p.currToken, p.currToken.Literal)
+		return nil
+	}
+
+	leading := p.takeComments()
 	key := p.currToken
 	p.NextToken()
 
 	if p.currToken.Type != token.COLON {
-		p.parserErr = newParserErr(ErrMissingKeyvalSeparator, p.currToken.Row, p.currToken.Colm, p.currToken.Literal)
+		p.parserErr = newParserErr(ErrMissingKeyvalSeparator, p.currToken, p.currToken.Literal)
 		return nil
 	}
 
+	// comments between the key and the value go before the value
+	beforeColon := p.takeComments()
 	p.NextToken()
+	p.currComments = append(beforeColon, p.currComments...)
 
 	val := p.parseNode()
 	if p.parserErr != nil {
 		return nil
 	}
 
-	return ast.NewKeyVal(key, val)
+	keyval := ast.NewKeyVal(key, val)
+	keyval.Leading = leading
+	return keyval
 }
 
 func (p *Parser) NextToken() {
+	p.prevEnd = p.currToken.End()
 	p.currToken = p.peekToken
-	tok, ok := p.nextToken()
+	p.currComments = p.peekComments
+	p.peekToken, p.peekComments = p.pullToken()
+}
+
+// pulls the next token from the lexer and the comments before it
+func (p *Parser) pullToken() (token.Token, []ast.Comment) {
+	var comments []ast.Comment
+	for {
+		tok, ok := p.nextToken()
+
+		if !ok {
+			return newEOF(), comments
+		}
 
-	if !ok {
-		p.peekToken = newEOF()
+		if tok.Type == token.ERR {
+			p.readErr = &ReadError{Msg: tok.Literal}
+			tok.Type, tok.Literal = token.EOF, ""
+			return tok, comments
+		}
+
+		if !isComment(tok) {
+			p.lastRow = tok.Row
+			return tok, comments
+		}
+
+		comments = append(comments, ast.Comment{Token: tok, NewLine: tok.Row > p.lastRow})
+		p.lastRow = tok.Row + strings.Count(tok.Literal, "\n")
+	}
+}
+
+func (p *Parser) takeComments() []ast.Comment {
+	comments := p.currComments
+	p.currComments = nil
+	return comments
+}
+
+// takes the comments which are on the same line as the previous token
+func (p *Parser) takeSameLineComments() []ast.Comment {
+	i := 0
+	for i < len(p.currComments) && !p.currComments[i].NewLine {
+		i++
+	}
+
+	comments := p.currComments[:i:i]
+	p.currComments = p.currComments[i:]
+	return comments
+}
+
+func (p *Parser) attachTrailing(node ast.Node, comments []ast.Comment) {
+	if len(comments) == 0 {
 		return
 	}
 
-	p.peekToken = tok
+	nodeComments := node.NodeComments()
+	nodeComments.Trailing = append(nodeComments.Trailing, comments...)
+}
+
+// stores the error when recovering, otherwise sets it as the parser error.
+// returns if the parsing can continue
+func (p *Parser) recoverFrom(err *ParserError) bool {
+	if !p.opts.Recover {
+		p.parserErr = err
+		return false
+	}
+
+	p.addErr(err)
+	return true
+}
+
+// the same problem can be reported by every unclosed container
+func (p *Parser) addErr(err *ParserError) {
+	if n := len(p.errs); n > 0 {
+		last := p.errs[n-1]
+		if last.WrapError == err.WrapError && last.Row == err.Row && last.Colm == err.Colm {
+			return
+		}
+	}
+	p.errs = append(p.errs, err)
+}
+
+// skips the tokens until ',', ']' or '}' which is not nested and consumes the comma
+func (p *Parser) skipElement() {
+	depth := 0
+	for p.currToken.Type != token.EOF {
+		switch p.currToken.Type {
+		case token.LEFT_SQUARE, token.LEFT_CURLY:
+			depth++
+		case token.RIGHT_SQUARE, token.RIGHT_CURLY:
+			if depth == 0 {
+				return
+			}
+			depth--
+		case token.SEMICOLON:
+			if depth == 0 {
+				p.NextToken()
+				return
+			}
+		}
+		p.NextToken()
+	}
+}
+
+func (p *Parser) startsValue(tok token.Token) bool {
+	return isCurrLeaf(tok) || tok.Type == token.LEFT_SQUARE || tok.Type == token.LEFT_CURLY
+}
+
+// the trailing commas accepted by the last Parse
+func (p *Parser) TrailingCommas() []token.Token {
+	return p.trailingCommas
+}
+
+func (p *Parser) allowsTrailingComma() bool {
+	return p.opts.Dialect == token.JSON5 || p.opts.AllowTrailingCommas
+}
+
+func (p *Parser) isKey(tok token.Token) bool {
+	switch tok.Type {
+	case token.STRING_LITERAL:
+		return true
+	case token.SINGLE_STRING_LITERAL, token.IDENTIFIER:
+		return p.opts.Dialect == token.JSON5
+	default:
+		return false
+	}
 }
 
 func isCurrLeaf(tok token.Token) bool {
 	switch tok.Type {
-	case token.UNDEFINED, token.NULL, token.TRUE, token.FALSE, token.NUMBER_LITERAL, token.STRING_LITERAL:
+	case token.UNDEFINED, token.INVALID_UTF8, token.NULL, token.TRUE, token.FALSE, token.NUMBER_LITERAL, token.STRING_LITERAL, token.SINGLE_STRING_LITERAL:
 		return true
 	default:
 		return false
 	}
 }
 
+func isComment(tok token.Token) bool {
+	return tok.Type == token.LINE_COMMENT || tok.Type == token.BLOCK_COMMENT
+}
+
+func actual(tok token.Token) string {
+	if tok.Type == token.EOF {
+		return "EOF"
+	}
+	return tok.Literal
+}
+
 func newEOF() token.Token {
 	return token.New(token.EOF, "", -1, -1)
 }
@@ -1,14 +1,57 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/token"
 )
 
+// error with a stable code like JF1004, which doesn't change between versions.
+// the exported sentinel errors and ParserError implement it
+type CodedError interface {
+	error
+	Code() string
+}
+
+type codeError struct {
+	code string
+	msg  string
+}
+
+func newCodeError(code string, msg string) error {
+	return &codeError{
+		code: code,
+		msg:  msg,
+	}
+}
+
+func (err *codeError) Error() string {
+	return err.msg
+}
+
+func (err *codeError) Code() string {
+	return err.code
+}
+
+// the lexer couldn't read the input and reported it with an ERR token. it is
+// not a ParserError, the input which was read can be valid
+type ReadError struct {
+	Msg string
+}
+
+func (err *ReadError) Error() string {
+	return err.Msg
+}
+
 type ParserError struct {
 	WrapError error
 	Row       int
 	Colm      int
-	Actual    string
+	// byte offset in the input, it doesn't depend on the unit of Colm
+	Offset int
+	Actual string
 }
 
 func (err *ParserError) Error() string {
@@ -18,16 +61,47 @@ func (err *ParserError) Error() string {
 	return fmt.Sprintf("%s on row %d colm %d, but got %s", err.WrapError.Error(), err.Row, err.Colm, err.Actual)
 }
 
+// code of the wrapped sentinel error, empty if it has none
+func (err *ParserError) Code() string {
+	var coded CodedError
+	if errors.As(err.WrapError, &coded) {
+		return coded.Code()
+	}
+	return ""
+}
+
 // for errors.Unwrap
 func (err *ParserError) Unwrap() error {
 	return err.WrapError
 }
 
-func newParserErr(err error, row int, colm int, actual string) *ParserError {
+// the error is at the start of tok
+func newParserErr(err error, tok token.Token, actual string) *ParserError {
 	return &ParserError{
 		WrapError: err,
-		Row:       row,
-		Colm:      colm,
+		Row:       tok.Row,
+		Colm:      tok.Colm,
+		Offset:    tok.Offset,
 		Actual:    actual,
 	}
 }
+
+// all errors found while recovering, in the order of the input
+type ParserErrors []*ParserError
+
+func (errs ParserErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// for errors.Is and errors.As
+func (errs ParserErrors) Unwrap() []error {
+	wrapped := make([]error, len(errs))
+	for i, err := range errs {
+		wrapped[i] = err
+	}
+	return wrapped
+}
@@ -2,8 +2,10 @@ package parser
 
 import (
 	"errors"
+	"io"
 	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/lastvoidtemplar/json_formatter/internal/ast"
 	"github.com/lastvoidtemplar/json_formatter/internal/lexer"
@@ -776,3 +778,451 @@ func TestParserMultilineString(t *testing.T) {
 		t.Fatalf("Expected ErrInvalidType, but got %s", err.Error())
 	}
 }
+
+func commentLiterals(comments []ast.Comment) []string {
+	literals := make([]string, len(comments))
+	for i, comment := range comments {
+		literals[i] = comment.Token.Literal
+		if comment.NewLine {
+			literals[i] = "\n" + literals[i]
+		}
+	}
+	return literals
+}
+
+func compareComments(name string, actual []ast.Comment, expected []string, t *testing.T) {
+	literals := commentLiterals(actual)
+	if strings.Join(literals, "|") != strings.Join(expected, "|") {
+		t.Errorf("%s comments were expected to be %q, but got %q", name, expected, literals)
+	}
+}
+
+func TestParserComments(t *testing.T) {
+	input := `// head
+{
+	// first
+	"a": 1, // one
+	"b" /* key */ : /* val */ [
+		2 /* two */ , /* still two */
+		// three
+		3
+		// dangling
+	]
+	// end
+} // tail
+// eof`
+
+	lex := lexer.New(strings.NewReader(input))
+	parser, err := New(lex)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	root, err := parser.Parse()
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	object, ok := root.(*ast.ObjectNode)
+	if !ok {
+		t.Fatalf("Expected object, but got %T", root)
+	}
+
+	compareComments("root leading", object.Leading, []string{"\n// head"}, t)
+	compareComments("root trailing", object.Trailing, []string{"// tail", "\n// eof"}, t)
+	compareComments("object dangling", object.Dangling, []string{"\n// end"}, t)
+
+	a := object.Nodes[0]
+	compareComments("a leading", a.Leading, []string{"\n// first"}, t)
+	compareComments("a trailing", a.Trailing, []string{"// one"}, t)
+
+	b := object.Nodes[1]
+	compareComments("b leading", b.Leading, nil, t)
+	compareComments("b val leading", b.Val.NodeComments().Leading, []string{"/* key */", "/* val */"}, t)
+
+	array, ok := b.Val.(*ast.ArrayNode)
+	if !ok {
+		t.Fatalf("Expected array, but got %T", b.Val)
+	}
+
+	compareComments("2 trailing", array.Nodes[0].NodeComments().Trailing, []string{"/* two */", "/* still two */"}, t)
+	compareComments("3 leading", array.Nodes[1].NodeComments().Leading, []string{"\n// three"}, t)
+	compareComments("array dangling", array.Dangling, []string{"\n// dangling"}, t)
+}
+
+func TestParserOnlyComments(t *testing.T) {
+	input := `// nothing
+	/* here */`
+	lex := lexer.New(strings.NewReader(input))
+	_, err := New(lex)
+
+	if err != ErrEmptyLexer {
+		t.Fatalf("Expected ErrEmptyLexer, but got %v", err)
+	}
+}
+
+func TestParserJSON5(t *testing.T) {
+	input := `{unquoted: 'single', "double": [1, 2,], 'quoted': {a: +Infinity,},}`
+	lex := lexer.NewWithDialect(strings.NewReader(input), token.JSON5)
+	parser, err := NewWithDialect(lex, token.JSON5)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	root, err := parser.Parse()
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	expected := &ast.ObjectNode{
+		Type: ast.OBJECT,
+		Nodes: []*ast.KeyValNode{
+			{
+				Type: ast.KEYVAL,
+				Key:  token.Token{Type: token.IDENTIFIER, Literal: "unquoted"},
+				Val: &ast.StringNode{
+					Type:  ast.STRING,
+					Token: token.Token{Type: token.SINGLE_STRING_LITERAL, Literal: "single"},
+				},
+			},
+			{
+				Type: ast.KEYVAL,
+				Key:  token.Token{Type: token.STRING_LITERAL, Literal: "double"},
+				Val: &ast.ArrayNode{
+					Type: ast.ARRAY,
+					Nodes: []ast.Node{
+						&ast.NumberNode{Type: ast.NUMBER, Token: token.Token{Type: token.NUMBER_LITERAL, Literal: "1"}},
+						&ast.NumberNode{Type: ast.NUMBER, Token: token.Token{Type: token.NUMBER_LITERAL, Literal: "2"}},
+					},
+				},
+			},
+			{
+				Type: ast.KEYVAL,
+				Key:  token.Token{Type: token.SINGLE_STRING_LITERAL, Literal: "quoted"},
+				Val: &ast.ObjectNode{
+					Type: ast.OBJECT,
+					Nodes: []*ast.KeyValNode{
+						{
+							Type: ast.KEYVAL,
+							Key:  token.Token{Type: token.IDENTIFIER, Literal: "a"},
+							Val:  &ast.NumberNode{Type: ast.NUMBER, Token: token.Token{Type: token.NUMBER_LITERAL, Literal: "+Infinity"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	compareNode(root, expected, t)
+}
+
+func TestParserJSON5IdentifierValue(t *testing.T) {
+	input := `{key: value}`
+	lex := lexer.NewWithDialect(strings.NewReader(input), token.JSON5)
+	parser, err := NewWithDialect(lex, token.JSON5)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	_, err = parser.Parse()
+
+	if !errors.Is(err, ErrInvalidType) {
+		t.Fatalf("Expected ErrInvalidType, but got %v", err)
+	}
+}
+
+func TestParserJSONRejectsJSON5Keys(t *testing.T) {
+	input := `{'key': 1}`
+	lex := lexer.New(strings.NewReader(input))
+	parser, err := New(lex)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	_, err = parser.Parse()
+
+	if !errors.Is(err, ErrKeyNotString) {
+		t.Fatalf("Expected ErrKeyNotString, but got %v", err)
+	}
+}
+
+func TestParserAllowTrailingCommas(t *testing.T) {
+	input := `{
+		"key1": [1, 2,],
+		"key2": {"a": 1,},
+	}`
+
+	lex := lexer.New(strings.NewReader(input))
+	parser, err := NewWithOptions(lex, Options{AllowTrailingCommas: true})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = parser.Parse()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []token.Token{
+		{Type: token.SEMICOLON, Literal: ",", Row: 2, Colm: 16, Offset: 17, EndOffset: 18, EndRow: 2, EndColm: 17},
+		{Type: token.SEMICOLON, Literal: ",", Row: 3, Colm: 18, Offset: 38, EndOffset: 39, EndRow: 3, EndColm: 19},
+		{Type: token.SEMICOLON, Literal: ",", Row: 3, Colm: 20, Offset: 40, EndOffset: 41, EndRow: 3, EndColm: 21},
+	}
+
+	commas := parser.TrailingCommas()
+	if len(commas) != len(expected) {
+		t.Fatalf("Expected %d trailing commas, but got %d", len(expected), len(commas))
+	}
+
+	for i, comma := range commas {
+		if comma != expected[i] {
+			t.Errorf("comma[%d] was expected to be %v, but got %v", i, expected[i], comma)
+		}
+	}
+}
+
+func parseRecover(input string, t *testing.T) (ast.Node, ParserErrors) {
+	lex := lexer.New(strings.NewReader(input))
+	parser, err := NewWithOptions(lex, Options{Recover: true})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := parser.Parse()
+	if err == nil {
+		return root, nil
+	}
+
+	var errs ParserErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Expected ParserErrors, but got %T", err)
+	}
+	return root, errs
+}
+
+func TestParserRecoverMultipleErrors(t *testing.T) {
+	input := `{
+	"a": [1, 2 3],
+	"b": ture,
+	"c" 4,
+	"d": {"x": 1,},
+	"e": 5
+}`
+
+	root, errs := parseRecover(input, t)
+
+	expected := []struct {
+		err  error
+		row  int
+		colm int
+	}{
+		{ErrMissingArraySeparator, 2, 13},
+		{ErrInvalidType, 3, 7},
+		{ErrMissingKeyvalSeparator, 4, 6},
+		{ErrInvalidType, 5, 15},
+	}
+
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d errors, but got %d: %v", len(expected), len(errs), errs)
+	}
+
+	for i, err := range errs {
+		if !errors.Is(err, expected[i].err) || err.Row != expected[i].row || err.Colm != expected[i].colm {
+			t.Errorf("error[%d] was expected to be %v at %d:%d, but got %v at %d:%d",
+				i, expected[i].err, expected[i].row, expected[i].colm, err.WrapError, err.Row, err.Colm)
+		}
+	}
+
+	obj, ok := root.(*ast.ObjectNode)
+	if !ok {
+		t.Fatalf("Expected partial object, but got %T", root)
+	}
+
+	keys := []string{}
+	for _, keyval := range obj.Nodes {
+		keys = append(keys, keyval.Key.Literal)
+	}
+
+	if strings.Join(keys, " ") != "a d e" {
+		t.Errorf("Expected keys a d e, but got %v", keys)
+	}
+
+	arr := obj.Nodes[0].Val.(*ast.ArrayNode)
+	if len(arr.Nodes) != 3 {
+		t.Errorf("Expected the array to keep 3 elements, but got %d", len(arr.Nodes))
+	}
+}
+
+func TestParserRecoverUnclosed(t *testing.T) {
+	root, errs := parseRecover(`[1, {"a": 2, [3]`, t)
+
+	if len(errs) != 3 {
+		t.Fatalf("Expected 3 errors, but got %d: %v", len(errs), errs)
+	}
+
+	if !errors.Is(errs[0], ErrKeyNotString) {
+		t.Errorf("Expected %v, but got %v", ErrKeyNotString, errs[0])
+	}
+	if !errors.Is(errs[1], ErrMissingObjectClosingBracket) {
+		t.Errorf("Expected %v, but got %v", ErrMissingObjectClosingBracket, errs[1])
+	}
+	if !errors.Is(errs[2], ErrMissingArrayClosingBracket) {
+		t.Errorf("Expected %v, but got %v", ErrMissingArrayClosingBracket, errs[2])
+	}
+
+	arr, ok := root.(*ast.ArrayNode)
+	if !ok || len(arr.Nodes) != 2 {
+		t.Fatalf("Expected partial array with 2 elements, but got %v", root)
+	}
+}
+
+func TestParserRecoverExtraTokens(t *testing.T) {
+	root, errs := parseRecover(`{"a": 1} 2`, t)
+
+	if len(errs) != 1 || !errors.Is(errs[0], ErrExtraTokens) {
+		t.Fatalf("Expected %v, but got %v", ErrExtraTokens, errs)
+	}
+
+	if root == nil {
+		t.Fatal("Expected the root to be kept")
+	}
+}
+
+func TestParserRecoverValid(t *testing.T) {
+	root, errs := parseRecover(`{"a": [1, 2]}`, t)
+
+	if errs != nil {
+		t.Fatal(errs)
+	}
+
+	if root == nil {
+		t.Fatal("Expected root")
+	}
+}
+
+func TestParserErrorCode(t *testing.T) {
+	lex := lexer.New(strings.NewReader(`{"a": 1, "a": 2}`))
+	parser, err := New(lex)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = parser.Parse()
+
+	var coded CodedError
+	if !errors.As(err, &coded) {
+		t.Fatalf("Expected CodedError, but got %T", err)
+	}
+
+	if coded.Code() != "JF1007" {
+		t.Fatalf("Expected code JF1007, but got %s", coded.Code())
+	}
+
+	if !errors.As(ErrEmptyLexer, &coded) || coded.Code() != "JF1010" {
+		t.Fatalf("Expected ErrEmptyLexer to have code JF1010")
+	}
+}
+
+func TestParserSpans(t *testing.T) {
+	input := "{\n  \"a\": [1, true],\n  \"b\": {}\n}"
+
+	lex := lexer.New(strings.NewReader(input))
+	parser, err := New(lex)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := parser.Parse()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	obj := root.(*ast.ObjectNode)
+	keyval := obj.Nodes[0]
+	arr := keyval.Val.(*ast.ArrayNode)
+
+	tests := []struct {
+		name     string
+		node     ast.Node
+		expected string
+	}{
+		{"object", obj, "{\n  \"a\": [1, true],\n  \"b\": {}\n}"},
+		{"keyval", keyval, `"a": [1, true]`},
+		{"array", arr, "[1, true]"},
+		{"bool", arr.Nodes[1], "true"},
+		{"empty object", obj.Nodes[1].Val, "{}"},
+	}
+
+	for _, test := range tests {
+		span := test.node.NodeSpan()
+		if actual := input[span.Start.Offset:span.End.Offset]; actual != test.expected {
+			t.Errorf("%s span was expected to be %q, but got %q", test.name, test.expected, actual)
+		}
+	}
+
+	span := arr.NodeSpan()
+	if span.Start.Row != 2 || span.Start.Colm != 8 || span.End.Row != 2 || span.End.Colm != 17 {
+		t.Errorf("Expected array span 2:8-2:17, but got %d:%d-%d:%d", span.Start.Row, span.Start.Colm, span.End.Row, span.End.Colm)
+	}
+}
+
+func TestParserSpansRecover(t *testing.T) {
+	input := `[1, [2, 3`
+	root, _ := parseRecover(input, t)
+
+	arr := root.(*ast.ArrayNode)
+	inner := arr.Nodes[1]
+
+	span := inner.NodeSpan()
+	if actual := input[span.Start.Offset:span.End.Offset]; actual != "[2, 3" {
+		t.Errorf("Expected the partial array to end after its last element, but got %q", actual)
+	}
+}
+
+func TestParserInvalidUTF8(t *testing.T) {
+	_, errs := parseRecover("{\"caf\xe9\": 1, \"a\": \"\xff\"}", t)
+
+	if len(errs) != 2 {
+		t.Fatalf("Expected 2 errors, but got %v", errs)
+	}
+
+	for _, err := range errs {
+		if !errors.Is(err, ErrInvalidUTF8) {
+			t.Errorf("Expected %v, but got %v", ErrInvalidUTF8, err)
+		}
+	}
+
+	if errs[1].Offset != 17 {
+		t.Errorf("Expected the second error at offset 17, but got %d", errs[1].Offset)
+	}
+}
+
+func TestParserReadErr(t *testing.T) {
+	readErr := errors.New("broken pipe")
+	inputs := []string{"", "[1, 2", "[1, 2]", "[1 2"}
+
+	for _, input := range inputs {
+		lex := lexer.New(io.MultiReader(strings.NewReader(input), iotest.ErrReader(readErr)))
+		p, err := New(lex)
+		if err == nil {
+			_, err = p.Parse()
+		}
+
+		var parserErr *ParserError
+		if err == nil || err.Error() != readErr.Error() || errors.As(err, &parserErr) {
+			t.Errorf("Expected the read error for %q, but got %v", input, err)
+		}
+	}
+}
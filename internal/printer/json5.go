@@ -0,0 +1,170 @@
+package printer
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/ast"
+	"github.com/lastvoidtemplar/json_formatter/internal/token"
+)
+
+// the lexer keeps string literals without the quotes and with escapes as is
+func (p *Printer) leafText(node ast.LeafNode) string {
+	switch n := node.(type) {
+	case *ast.StringNode:
+		return p.valueText(n.Token)
+	case *ast.NumberNode:
+		return p.valueText(n.Token)
+	default:
+		return node.Literal()
+	}
+}
+
+// like leafText, but for the token of the leaf
+func (p *Printer) valueText(tok token.Token) string {
+	switch tok.Type {
+	case token.STRING_LITERAL, token.SINGLE_STRING_LITERAL:
+		return p.stringText(tok)
+	case token.NUMBER_LITERAL:
+		if p.cfg.StrictJSON {
+			return toJSONNumber(tok.Literal)
+		}
+		return tok.Literal
+	default:
+		return tok.Literal
+	}
+}
+
+func (p *Printer) keyText(key token.Token) string {
+	if key.Type == token.IDENTIFIER && !p.cfg.StrictJSON {
+		return key.Literal
+	}
+	return p.stringText(key)
+}
+
+func (p *Printer) stringText(tok token.Token) string {
+	if !p.cfg.StrictJSON {
+		if tok.Type == token.SINGLE_STRING_LITERAL {
+			return "'" + tok.Literal + "'"
+		}
+		return `"` + tok.Literal + `"`
+	}
+
+	return `"` + toJSONString(tok.Literal, tok.Type) + `"`
+}
+
+// rewrites the json5 escapes to json ones. identifiers can have only \u escapes
+func toJSONString(literal string, typ token.TokenType) string {
+	var sb strings.Builder
+	sb.Grow(len(literal))
+
+	for i := 0; i < len(literal); {
+		r, size := utf8.DecodeRuneInString(literal[i:])
+		switch {
+		case r == '"' && typ != token.STRING_LITERAL:
+			sb.WriteString(`\"`)
+		case r == '\\':
+			size = writeJSONEscape(&sb, literal[i:])
+		case r < 0x20:
+			writeControl(&sb, r)
+		default:
+			sb.WriteString(literal[i : i+size])
+		}
+		i += size
+	}
+
+	return sb.String()
+}
+
+// writes the escape at the start of s, returns its length
+func writeJSONEscape(sb *strings.Builder, s string) int {
+	if len(s) < 2 {
+		return len(s)
+	}
+
+	switch s[1] {
+	case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+		sb.WriteString(s[:2])
+		return 2
+	case 'u':
+		sb.WriteString(s[:6])
+		return 6
+	case 'x':
+		sb.WriteString(`\u00`)
+		sb.WriteString(s[2:4])
+		return 4
+	case '0':
+		sb.WriteString(`\u0000`)
+		return 2
+	case 'v':
+		sb.WriteString(`\u000b`)
+		return 2
+	case '\n':
+		// line continuation
+		return 2
+	case '\r':
+		if len(s) > 2 && s[2] == '\n' {
+			return 3
+		}
+		return 2
+	}
+
+	r, size := utf8.DecodeRuneInString(s[1:])
+	switch {
+	case r == '\u2028' || r == '\u2029':
+		// line continuation
+	case r < 0x20:
+		writeControl(sb, r)
+	default:
+		// any other escaped char is the char itself
+		sb.WriteString(s[1 : 1+size])
+	}
+	return 1 + size
+}
+
+func writeControl(sb *strings.Builder, r rune) {
+	switch r {
+	case '\t':
+		sb.WriteString(`\t`)
+	default:
+		fmt.Fprintf(sb, `\u%04x`, r)
+	}
+}
+
+// json has no Infinity and NaN, they become null like in JSON.stringify
+func toJSONNumber(literal string) string {
+	literal = strings.TrimPrefix(literal, "+")
+
+	sign := ""
+	digits := literal
+	if strings.HasPrefix(digits, "-") {
+		sign = "-"
+		digits = digits[1:]
+	}
+
+	if digits == "Infinity" || digits == "NaN" {
+		return "null"
+	}
+
+	if strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X") {
+		num, ok := new(big.Int).SetString(digits[2:], 16)
+		if !ok {
+			return literal
+		}
+		return sign + num.String()
+	}
+
+	mantissa, exponent := digits, ""
+	if ind := strings.IndexAny(digits, "eE"); ind != -1 {
+		mantissa, exponent = digits[:ind], digits[ind:]
+	}
+
+	if strings.HasPrefix(mantissa, ".") {
+		mantissa = "0" + mantissa
+	}
+	mantissa = strings.TrimSuffix(mantissa, ".")
+
+	return sign + mantissa + exponent
+}
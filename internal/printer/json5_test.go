@@ -0,0 +1,94 @@
+package printer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/lexer"
+	"github.com/lastvoidtemplar/json_formatter/internal/parser"
+	"github.com/lastvoidtemplar/json_formatter/internal/token"
+)
+
+func formatJSON5(input string, cfg Config, t *testing.T) string {
+	lex := lexer.NewWithDialect(strings.NewReader(input), token.JSON5)
+	p, err := parser.NewWithDialect(lex, token.JSON5)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	root, err := p.Parse()
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var sb strings.Builder
+	err = New(&sb, cfg).Print(root)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	return sb.String()
+}
+
+func TestPrinterJSON5(t *testing.T) {
+	input := `// config
+{key: 'it\'s "quoted"', hex: 0xFF, nums: [.5, 5., +1, -Infinity, NaN,],}`
+
+	expected := `// config
+{
+  key: 'it\'s "quoted"',
+  hex: 0xFF,
+  nums: [.5, 5., +1, -Infinity, NaN]
+}
+`
+
+	actual := formatJSON5(input, Config{Indent: "  ", Newline: "\n", MaxWidth: 40}, t)
+
+	if actual != expected {
+		t.Fatalf("Expected:\n%s\nbut got:\n%s", expected, actual)
+	}
+}
+
+func TestPrinterStrictJSON(t *testing.T) {
+	input := `// config
+{key: 'it\'s "quoted"\x41\0\v', "dq": "\'a\
+b", hex: -0xFF, nums: [.5, 5., +1, 1.e5, -Infinity, NaN,], ab: 'tab	\/'}`
+
+	expected := `{"key":"it's \"quoted\"\u0041\u0000\u000b","dq":"'ab","hex":-255,"nums":[0.5,5,1,1e5,null,null],"ab":"tab\t\/"}`
+
+	actual := formatJSON5(input, Config{Compact: true, StrictJSON: true}, t)
+
+	if actual != expected {
+		t.Fatalf("Expected:\n%s\nbut got:\n%s", expected, actual)
+	}
+}
+
+func TestPrinterTrailingCommas(t *testing.T) {
+	input := `{a: [1, 2], b: [{c: 3,}, 'long enough to expand',],}`
+
+	expected := `{
+  a: [1, 2],
+  b: [
+    {c: 3},
+    'long enough to expand',
+  ],
+}
+`
+
+	actual := formatJSON5(input, Config{Indent: "  ", Newline: "\n", MaxWidth: 30, TrailingCommas: true}, t)
+
+	if actual != expected {
+		t.Fatalf("Expected:\n%s\nbut got:\n%s", expected, actual)
+	}
+
+	expected = `{"a":[1,2],"b":[{"c":3},"long enough to expand"]}`
+
+	actual = formatJSON5(input, Config{Compact: true, StrictJSON: true, TrailingCommas: true}, t)
+
+	if actual != expected {
+		t.Fatalf("Expected %s, but got %s", expected, actual)
+	}
+}
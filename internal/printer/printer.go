@@ -0,0 +1,321 @@
+package printer
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/ast"
+)
+
+type Config struct {
+	Indent  string
+	Newline string
+	// no whitespace between the tokens. Newline is still written after the root
+	Compact bool
+	// arrays and objects which fit in MaxWidth columns are kept on a single line.
+	// 0 means that they are always expanded
+	MaxWidth int
+	// order of the object keys
+	SortKeys KeyOrder
+	// keys which always go first in the given order, no matter SortKeys
+	KeyPriority []string
+	// sort only the keys of the root object
+	SortTopLevelOnly bool
+	// converts json5 input to json. the comments are dropped as well
+	StrictJSON bool
+	// comma after the last element of expanded arrays and objects, valid only in json5.
+	// ignored with StrictJSON and Compact
+	TrailingCommas bool
+}
+
+// columns taken by a tab in the indent when MaxWidth is checked
+const tabWidth = 4
+
+func DefaultConfig() Config {
+	return Config{
+		Indent:  "    ",
+		Newline: "\n",
+	}
+}
+
+// builds indent string from width, tab counts as a single indent level
+func NewIndent(width int, useTabs bool) string {
+	if useTabs {
+		return "\t"
+	}
+	return strings.Repeat(" ", width)
+}
+
+type Printer struct {
+	w   io.Writer
+	cfg Config
+	err error
+	// current column, used only for MaxWidth
+	colm int
+	// inside a container which is printed on a single line
+	flat bool
+}
+
+func New(w io.Writer, cfg Config) *Printer {
+	return &Printer{
+		w:   w,
+		cfg: cfg,
+	}
+}
+
+// writes the node followed by newline, returns the first write error
+func (p *Printer) Print(node ast.Node) error {
+	comments := node.NodeComments()
+	p.writeLeading(comments.Leading, 0, true)
+	p.printNode(node, 0, 0)
+	p.writeTrailing(comments.Trailing, 0, false)
+	p.writeString(p.cfg.Newline)
+
+	err := p.err
+	p.err = nil
+	p.colm = 0
+	return err
+}
+
+// trailing is the number of characters which will follow the node on its line
+func (p *Printer) printNode(node ast.Node, depth int, trailing int) {
+	switch n := node.(type) {
+	case ast.LeafNode:
+		p.writeString(p.leafText(n))
+	case *ast.ArrayNode:
+		p.printArray(n, depth, trailing)
+	case *ast.ObjectNode:
+		p.printObject(n, depth, trailing)
+	case *ast.KeyValNode:
+		p.printKeyVal(n, depth, trailing)
+	}
+}
+
+func (p *Printer) printArray(array *ast.ArrayNode, depth int, trailing int) {
+	if len(array.Nodes) == 0 && !p.hasComments(array.Dangling) {
+		p.writeString("[]")
+		return
+	}
+
+	restore := p.enterFlat(array, trailing)
+	defer restore()
+
+	p.writeString("[")
+	p.printElements(array.Nodes, array.Dangling, depth)
+	p.writeNewline(depth)
+	p.writeString("]")
+}
+
+func (p *Printer) printObject(object *ast.ObjectNode, depth int, trailing int) {
+	if len(object.Nodes) == 0 && !p.hasComments(object.Dangling) {
+		p.writeString("{}")
+		return
+	}
+
+	restore := p.enterFlat(object, trailing)
+	defer restore()
+
+	keyvals := p.sortedKeyVals(object, depth)
+	nodes := make([]ast.Node, len(keyvals))
+	for i, keyval := range keyvals {
+		nodes[i] = keyval
+	}
+
+	p.writeString("{")
+	p.printElements(nodes, object.Dangling, depth)
+	p.writeNewline(depth)
+	p.writeString("}")
+}
+
+// prints the elements of array or object, each followed by its comma and comments
+func (p *Printer) printElements(nodes []ast.Node, dangling []ast.Comment, depth int) {
+	last := len(nodes) - 1
+	for i, node := range nodes {
+		comments := node.NodeComments()
+		p.writeNewline(depth + 1)
+		p.writeLeading(comments.Leading, depth+1, true)
+		p.printNode(node, depth+1, p.elementTrailing(i, last))
+		if i < last {
+			p.writeSeparator()
+		} else if p.hasTrailingComma() {
+			p.writeString(",")
+		}
+		p.writeTrailing(comments.Trailing, depth+1, false)
+	}
+	p.writeTrailing(dangling, depth+1, len(nodes) == 0)
+}
+
+func (p *Printer) printKeyVal(keyval *ast.KeyValNode, depth int, trailing int) {
+	p.writeString(p.keyText(keyval.Key))
+	if p.cfg.Compact {
+		p.writeString(":")
+	} else {
+		p.writeString(": ")
+	}
+	p.writeLeading(keyval.Val.NodeComments().Leading, depth, false)
+	p.printNode(keyval.Val, depth, trailing)
+}
+
+// every element except the last one is followed by a comma
+func (p *Printer) elementTrailing(i int, last int) int {
+	if i < last || p.hasTrailingComma() {
+		return 1
+	}
+	return 0
+}
+
+func (p *Printer) hasTrailingComma() bool {
+	return p.cfg.TrailingCommas && !p.cfg.StrictJSON && !p.cfg.Compact && !p.flat
+}
+
+// switches to single line mode if the container fits in the line,
+// the returned func restores the previous mode
+func (p *Printer) enterFlat(node ast.Node, trailing int) func() {
+	if p.flat || p.cfg.Compact || p.cfg.MaxWidth <= 0 {
+		return func() {}
+	}
+
+	limit := p.cfg.MaxWidth - p.colm - trailing
+	if p.flatWidth(node, limit) > limit {
+		return func() {}
+	}
+
+	p.flat = true
+	return func() {
+		p.flat = false
+	}
+}
+
+// width of the node printed on a single line. stops counting once limit is exceeded.
+// containers with comments inside can't be printed on a single line
+func (p *Printer) flatWidth(node ast.Node, limit int) int {
+	switch n := node.(type) {
+	case ast.LeafNode:
+		return utf8.RuneCountInString(p.leafText(n))
+	case *ast.ArrayNode:
+		if len(n.Dangling) > 0 {
+			return limit + 1
+		}
+
+		width := 2 + 2*max(len(n.Nodes)-1, 0)
+		for _, child := range n.Nodes {
+			if width > limit || !child.NodeComments().Empty() {
+				return limit + 1
+			}
+			width += p.flatWidth(child, limit-width)
+		}
+		return width
+	case *ast.ObjectNode:
+		if len(n.Dangling) > 0 {
+			return limit + 1
+		}
+
+		width := 2 + 2*max(len(n.Nodes)-1, 0)
+		for _, keyval := range n.Nodes {
+			if width > limit || !keyval.Comments.Empty() || !keyval.Val.NodeComments().Empty() {
+				return limit + 1
+			}
+			width += p.flatWidth(keyval, limit-width)
+		}
+		return width
+	case *ast.KeyValNode:
+		width := utf8.RuneCountInString(p.keyText(n.Key)) + 2
+		return width + p.flatWidth(n.Val, limit-width)
+	default:
+		return 0
+	}
+}
+
+func (p *Printer) writeSeparator() {
+	p.writeString(",")
+	if p.flat {
+		p.writeString(" ")
+	}
+}
+
+// compact and strict output drop the comments
+func (p *Printer) hasComments(comments []ast.Comment) bool {
+	return len(comments) > 0 && !p.cfg.Compact && !p.cfg.StrictJSON
+}
+
+// comments before a node. the ones which were on their own line stay on their
+// own line. the node starts a new line when the last comment starts a line,
+// as the comment is first on its line when the output is formatted again.
+// lineStart tells if the first comment starts a line
+func (p *Printer) writeLeading(comments []ast.Comment, depth int, lineStart bool) {
+	if !p.hasComments(comments) {
+		return
+	}
+
+	for i, comment := range comments {
+		if i > 0 {
+			lineStart = comment.NewLine || comments[i-1].IsLine()
+			if lineStart {
+				p.writeNewline(depth)
+			} else {
+				p.writeString(" ")
+			}
+		}
+		p.writeString(comment.Token.Literal)
+	}
+
+	if lineStart || comments[len(comments)-1].IsLine() {
+		p.writeNewline(depth)
+	} else {
+		p.writeString(" ")
+	}
+}
+
+// comments after a node. the ones which were on the same line stay on the same
+// line. with lineStart the first one starts a line, like the dangling comments
+// of an empty container
+func (p *Printer) writeTrailing(comments []ast.Comment, depth int, lineStart bool) {
+	if !p.hasComments(comments) {
+		return
+	}
+
+	for i, comment := range comments {
+		if comment.NewLine || i == 0 && lineStart || i > 0 && comments[i-1].IsLine() {
+			p.writeNewline(depth)
+		} else {
+			p.writeString(" ")
+		}
+		p.writeString(comment.Token.Literal)
+	}
+}
+
+func (p *Printer) writeNewline(depth int) {
+	if p.cfg.Compact || p.flat {
+		return
+	}
+
+	p.writeString(p.cfg.Newline)
+	p.colm = 0
+	for range depth {
+		p.writeString(p.cfg.Indent)
+	}
+}
+
+func (p *Printer) writeString(s string) {
+	if p.err != nil {
+		return
+	}
+	_, p.err = io.WriteString(p.w, s)
+
+	if p.cfg.MaxWidth > 0 {
+		p.colm += textWidth(s)
+	}
+}
+
+func textWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if r == '\t' {
+			width += tabWidth
+		} else {
+			width++
+		}
+	}
+	return width
+}
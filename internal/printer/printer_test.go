@@ -0,0 +1,266 @@
+package printer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/lexer"
+	"github.com/lastvoidtemplar/json_formatter/internal/parser"
+)
+
+func format(input string, cfg Config, t *testing.T) string {
+	lex := lexer.New(strings.NewReader(input))
+	p, err := parser.New(lex)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	root, err := p.Parse()
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var sb strings.Builder
+	err = New(&sb, cfg).Print(root)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	return sb.String()
+}
+
+func TestPrinterObject(t *testing.T) {
+	input := `{"name":"Jason","age":27,"human":true,"hobbies":["Programming",false,42.69,null],"escaped":"a\"bU"}`
+
+	expected := `{
+    "name": "Jason",
+    "age": 27,
+    "human": true,
+    "hobbies": [
+        "Programming",
+        false,
+        42.69,
+        null
+    ],
+    "escaped": "a\"bU"
+}
+`
+
+	actual := format(input, DefaultConfig(), t)
+
+	if actual != expected {
+		t.Fatalf("Expected:\n%s\nbut got:\n%s", expected, actual)
+	}
+}
+
+func TestPrinterNested(t *testing.T) {
+	input := `[ {"a": [ [], {} ]}, [1, [2]] ]`
+
+	expected := "[\r\n" +
+		"\t{\r\n" +
+		"\t\t\"a\": [\r\n" +
+		"\t\t\t[],\r\n" +
+		"\t\t\t{}\r\n" +
+		"\t\t]\r\n" +
+		"\t},\r\n" +
+		"\t[\r\n" +
+		"\t\t1,\r\n" +
+		"\t\t[\r\n" +
+		"\t\t\t2\r\n" +
+		"\t\t]\r\n" +
+		"\t]\r\n" +
+		"]\r\n"
+
+	actual := format(input, Config{Indent: NewIndent(4, true), Newline: "\r\n"}, t)
+
+	if actual != expected {
+		t.Fatalf("Expected:\n%q\nbut got:\n%q", expected, actual)
+	}
+}
+
+func TestPrinterLeaf(t *testing.T) {
+	input := `  "Деян"  `
+
+	expected := "\"Деян\"\n"
+
+	actual := format(input, Config{Indent: NewIndent(2, false), Newline: "\n"}, t)
+
+	if actual != expected {
+		t.Fatalf("Expected %q, but got %q", expected, actual)
+	}
+}
+
+type failingWriter struct {
+	writes int
+}
+
+var errWrite = errors.New("write failed")
+
+func (w *failingWriter) Write(b []byte) (int, error) {
+	w.writes++
+	return 0, errWrite
+}
+
+func TestPrinterWriteError(t *testing.T) {
+	lex := lexer.New(strings.NewReader(`[1, 2, 3]`))
+	p, err := parser.New(lex)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	root, err := p.Parse()
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	w := &failingWriter{}
+	err = New(w, DefaultConfig()).Print(root)
+
+	if !errors.Is(err, errWrite) {
+		t.Fatalf("Expected errWrite, but got %v", err)
+	}
+
+	if w.writes != 1 {
+		t.Fatalf("Expected printer to stop after the first failed write, but got %d writes", w.writes)
+	}
+}
+
+func TestPrinterCompact(t *testing.T) {
+	input := `{
+		"name": "Jason",
+		"hobbies": [ "Programming", false, 42.69, null, [], {} ],
+		"address": { "city": "Sofia", "zip": "1000" }
+	}`
+
+	expected := `{"name":"Jason","hobbies":["Programming",false,42.69,null,[],{}],"address":{"city":"Sofia","zip":"1000"}}`
+
+	actual := format(input, Config{Indent: "  ", Compact: true}, t)
+
+	if actual != expected {
+		t.Fatalf("Expected %s, but got %s", expected, actual)
+	}
+}
+
+func TestPrinterMaxWidth(t *testing.T) {
+	input := `{"point": {"x": 1, "y": 2}, "matrix": [[1, 0, 0], [0, 1, 0], [0, 0, 1]], "tags": ["first", "second", "third", "fourth"], "empty": []}`
+
+	expected := `{
+  "point": {"x": 1, "y": 2},
+  "matrix": [[1, 0, 0], [0, 1, 0], [0, 0, 1]],
+  "tags": [
+    "first",
+    "second",
+    "third",
+    "fourth"
+  ],
+  "empty": []
+}
+`
+
+	actual := format(input, Config{Indent: "  ", Newline: "\n", MaxWidth: 46}, t)
+
+	if actual != expected {
+		t.Fatalf("Expected:\n%s\nbut got:\n%s", expected, actual)
+	}
+}
+
+func TestPrinterMaxWidthCountsTrailingComma(t *testing.T) {
+	input := `[[1, 2], [3, 4]]`
+
+	expected := "[\n\t[\n\t\t1,\n\t\t2\n\t],\n\t[3, 4]\n]\n"
+
+	// "\t[1, 2]," is 11 columns wide, but "\t[3, 4]" fits in 10
+	actual := format(input, Config{Indent: "\t", Newline: "\n", MaxWidth: 10}, t)
+
+	if actual != expected {
+		t.Fatalf("Expected %q, but got %q", expected, actual)
+	}
+}
+
+func TestPrinterMaxWidthRoot(t *testing.T) {
+	input := `{"a": [1, 2], "b": "Деян"}`
+
+	expected := "{\"a\": [1, 2], \"b\": \"Деян\"}\n"
+
+	actual := format(input, Config{Indent: "  ", Newline: "\n", MaxWidth: 26}, t)
+
+	if actual != expected {
+		t.Fatalf("Expected %q, but got %q", expected, actual)
+	}
+}
+
+func TestPrinterCommentsOwnLine(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[/*0*/0]`, "[\n    /*0*/\n    0\n]\n"},
+		{`{/*c*/"a": /*v*/ 1}`, "{\n    /*c*/\n    \"a\": /*v*/ 1\n}\n"},
+		{`[/*a*/ /*b*/ 1]`, "[\n    /*a*/ /*b*/ 1\n]\n"},
+		{"{\"a\": // l\n/*v*/ 1}", "{\n    \"a\": // l\n    /*v*/\n    1\n}\n"},
+		{`[/*only*/]`, "[\n    /*only*/\n]\n"},
+	}
+
+	for _, test := range tests {
+		actual := format(test.input, DefaultConfig(), t)
+		if actual != test.expected {
+			t.Errorf("Expected %q for %s, but got %q", test.expected, test.input, actual)
+		}
+
+		if again := format(actual, DefaultConfig(), t); again != actual {
+			t.Errorf("Expected formatting of %s to be stable, but got %q", test.input, again)
+		}
+	}
+}
+
+func TestPrinterComments(t *testing.T) {
+	input := `// settings
+{"a": 1, // one
+"b": [ // nothing
+], "c": /* inline */ [1,
+  // two
+  2 /* after two */
+  // dangling
+],
+"d": {}} // tail`
+
+	expected := `// settings
+{
+  "a": 1, // one
+  "b": [
+    // nothing
+  ],
+  "c": /* inline */ [
+    1,
+    // two
+    2 /* after two */
+    // dangling
+  ],
+  "d": {}
+} // tail
+`
+
+	actual := format(input, Config{Indent: "  ", Newline: "\n", MaxWidth: 80}, t)
+
+	if actual != expected {
+		t.Fatalf("Expected:\n%s\nbut got:\n%s", expected, actual)
+	}
+
+	if again := format(actual, Config{Indent: "  ", Newline: "\n", MaxWidth: 80}, t); again != actual {
+		t.Fatalf("Expected formatting to be stable, but got:\n%s", again)
+	}
+
+	expected = `{"a":1,"b":[],"c":[1,2],"d":{}}`
+
+	actual = format(input, Config{Compact: true}, t)
+
+	if actual != expected {
+		t.Fatalf("Expected %s, but got %s", expected, actual)
+	}
+}
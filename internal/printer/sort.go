@@ -0,0 +1,105 @@
+package printer
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/ast"
+)
+
+type KeyOrder byte
+
+const (
+	// keys are printed in the order of the input
+	KEEP_ORDER KeyOrder = iota
+	// byte order of the key literals
+	LEXICOGRAPHIC
+	// like LEXICOGRAPHIC, but runs of digits are compared by their value ("a2" < "a10")
+	NATURAL
+)
+
+// returns the keyvals in printing order, the object itself is not modified
+func (p *Printer) sortedKeyVals(object *ast.ObjectNode, depth int) []*ast.KeyValNode {
+	if p.cfg.SortKeys == KEEP_ORDER && len(p.cfg.KeyPriority) == 0 {
+		return object.Nodes
+	}
+
+	if p.cfg.SortTopLevelOnly && depth > 0 {
+		return object.Nodes
+	}
+
+	keyvals := slices.Clone(object.Nodes)
+	slices.SortStableFunc(keyvals, func(a *ast.KeyValNode, b *ast.KeyValNode) int {
+		return p.compareKeys(a.Key.Literal, b.Key.Literal)
+	})
+	return keyvals
+}
+
+// keys from KeyPriority go first in the order of the list, the rest by SortKeys
+func (p *Printer) compareKeys(a string, b string) int {
+	priorityA := slices.Index(p.cfg.KeyPriority, a)
+	priorityB := slices.Index(p.cfg.KeyPriority, b)
+
+	switch {
+	case priorityA >= 0 && priorityB >= 0:
+		return priorityA - priorityB
+	case priorityA >= 0:
+		return -1
+	case priorityB >= 0:
+		return 1
+	}
+
+	switch p.cfg.SortKeys {
+	case LEXICOGRAPHIC:
+		return strings.Compare(a, b)
+	case NATURAL:
+		return compareNatural(a, b)
+	default:
+		return 0
+	}
+}
+
+func compareNatural(a string, b string) int {
+	// "01" and "1" have the same value, if nothing else differs the shorter one goes first
+	tie := 0
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if !isDigit(a[i]) || !isDigit(b[j]) {
+			if a[i] != b[j] {
+				return int(a[i]) - int(b[j])
+			}
+			i++
+			j++
+			continue
+		}
+
+		startA, startB := i, j
+		for i < len(a) && isDigit(a[i]) {
+			i++
+		}
+		for j < len(b) && isDigit(b[j]) {
+			j++
+		}
+
+		numA := strings.TrimLeft(a[startA:i], "0")
+		numB := strings.TrimLeft(b[startB:j], "0")
+		if len(numA) != len(numB) {
+			return len(numA) - len(numB)
+		}
+		if cmp := strings.Compare(numA, numB); cmp != 0 {
+			return cmp
+		}
+		if tie == 0 {
+			tie = (i - startA) - (j - startB)
+		}
+	}
+
+	if cmp := (len(a) - i) - (len(b) - j); cmp != 0 {
+		return cmp
+	}
+	return tie
+}
+
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
@@ -0,0 +1,54 @@
+package printer
+
+import (
+	"testing"
+)
+
+func TestCompareNatural(t *testing.T) {
+	ordered := []string{"", "a", "a1", "a01b", "a1c", "a2", "a10", "a10b", "ab", "b"}
+
+	for i := range ordered {
+		for j := range ordered {
+			cmp := compareNatural(ordered[i], ordered[j])
+			if i < j && cmp >= 0 || i > j && cmp <= 0 || i == j && cmp != 0 {
+				t.Errorf("Wrong order of %q and %q, got %d", ordered[i], ordered[j], cmp)
+			}
+		}
+	}
+}
+
+func TestPrinterSortKeys(t *testing.T) {
+	input := `{"b": {"z": 1, "a": 2}, "item10": 0, "item2": 0, "id": 1, "a": [{"y": 1, "x": 2}]}`
+
+	expected := `{"a":[{"x":2,"y":1}],"b":{"a":2,"z":1},"id":1,"item10":0,"item2":0}`
+	actual := format(input, Config{Compact: true, SortKeys: LEXICOGRAPHIC}, t)
+
+	if actual != expected {
+		t.Fatalf("Expected %s, but got %s", expected, actual)
+	}
+
+	expected = `{"a":[{"x":2,"y":1}],"b":{"a":2,"z":1},"id":1,"item2":0,"item10":0}`
+	actual = format(input, Config{Compact: true, SortKeys: NATURAL}, t)
+
+	if actual != expected {
+		t.Fatalf("Expected %s, but got %s", expected, actual)
+	}
+}
+
+func TestPrinterSortKeysPriority(t *testing.T) {
+	input := `{"b": {"z": 1, "name": 2, "id": 3}, "name": "n", "a": 0, "id": 1}`
+
+	expected := `{"id":1,"name":"n","b":{"z":1,"name":2,"id":3},"a":0}`
+	actual := format(input, Config{Compact: true, KeyPriority: []string{"id", "name"}, SortTopLevelOnly: true}, t)
+
+	if actual != expected {
+		t.Fatalf("Expected %s, but got %s", expected, actual)
+	}
+
+	expected = `{"id":1,"name":"n","a":0,"b":{"id":3,"name":2,"z":1}}`
+	actual = format(input, Config{Compact: true, SortKeys: LEXICOGRAPHIC, KeyPriority: []string{"id", "name"}}, t)
+
+	if actual != expected {
+		t.Fatalf("Expected %s, but got %s", expected, actual)
+	}
+}
@@ -0,0 +1,169 @@
+package printer
+
+import (
+	"errors"
+	"io"
+	"iter"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/ast"
+	"github.com/lastvoidtemplar/json_formatter/internal/parser"
+)
+
+// sorting and MaxWidth need the whole container before it is printed
+var ErrStreamUnsupported = errors.New("sorting the keys and MaxWidth can't be used when streaming")
+
+// prints the events of the parser as they come, so the memory depends only on
+// the nesting and not on the size of the document when the parser has
+// AllowDuplicateKeys. the output is the same as the output of Printer for the
+// AST of the document
+type Stream struct {
+	p *Printer
+
+	next func() (parser.Event, error, bool)
+	// the event which is printed next
+	event parser.Event
+	// the error of the parser or the end of the events
+	err  error
+	done bool
+}
+
+func NewStream(w io.Writer, cfg Config) (*Stream, error) {
+	if cfg.SortKeys != KEEP_ORDER || len(cfg.KeyPriority) > 0 || cfg.MaxWidth > 0 && !cfg.Compact {
+		return nil, ErrStreamUnsupported
+	}
+
+	return &Stream{p: New(w, cfg)}, nil
+}
+
+// prints the document followed by newline. returns the first error of the
+// parser or the writer, the output before the error is already written
+func (s *Stream) Print(events iter.Seq2[parser.Event, error]) error {
+	next, stop := iter.Pull2(events)
+	defer stop()
+
+	s.next = next
+	s.err = nil
+	s.done = false
+	s.advance()
+
+	s.p.writeLeading(s.takeComments(false), 0, true)
+	s.printValue(0)
+	s.p.writeTrailing(s.takeComments(true), 0, false)
+
+	if s.err == nil && !s.done {
+		// the parser reports the extra tokens, this is a bug in it
+		s.err = errors.New("the events continue after the root value")
+	}
+	if s.err != nil {
+		return s.err
+	}
+
+	s.p.writeString(s.p.cfg.Newline)
+	err := s.p.err
+	s.p.err = nil
+	return err
+}
+
+func (s *Stream) advance() {
+	if s.done {
+		return
+	}
+
+	event, err, ok := s.next()
+	switch {
+	case !ok:
+		s.done = true
+		s.event = parser.Event{}
+	case err != nil:
+		s.done = true
+		s.err = err
+		s.event = parser.Event{}
+	default:
+		s.event = event
+	}
+}
+
+// takes the following comments which are trailing or not
+func (s *Stream) takeComments(trailing bool) []ast.Comment {
+	var comments []ast.Comment
+	for !s.done && s.event.Type == parser.COMMENT && s.event.Trailing == trailing {
+		comments = append(comments, ast.Comment{Token: s.event.Token, NewLine: s.event.NewLine})
+		s.advance()
+	}
+	return comments
+}
+
+func (s *Stream) printValue(depth int) {
+	if s.done {
+		return
+	}
+
+	switch s.event.Type {
+	case parser.VALUE:
+		s.p.writeString(s.p.valueText(s.event.Token))
+		s.advance()
+	case parser.START_ARRAY:
+		s.printContainer(parser.END_ARRAY, "[", "]", depth)
+	case parser.START_OBJECT:
+		s.printContainer(parser.END_OBJECT, "{", "}", depth)
+	}
+}
+
+// like printArray and printObject
+func (s *Stream) printContainer(end parser.EventType, open string, close string, depth int) {
+	s.advance()
+
+	// the comments before the first element or the dangling comments of an empty container
+	leading := s.takeComments(false)
+	if !s.done && s.event.Type == end && !s.p.hasComments(leading) {
+		s.p.writeString(open + close)
+		s.advance()
+		return
+	}
+
+	s.p.writeString(open)
+	empty := true
+	for !s.done && s.event.Type != end {
+		empty = false
+		s.p.writeNewline(depth + 1)
+		s.p.writeLeading(leading, depth+1, true)
+		s.printElement(depth + 1)
+
+		trailing := s.takeComments(true)
+		leading = s.takeComments(false)
+		if !s.done && s.event.Type != end {
+			s.p.writeSeparator()
+		} else if s.p.hasTrailingComma() {
+			s.p.writeString(",")
+		}
+		s.p.writeTrailing(trailing, depth+1, false)
+	}
+
+	if s.done {
+		return
+	}
+
+	s.p.writeTrailing(leading, depth+1, empty)
+	s.p.writeNewline(depth)
+	s.p.writeString(close)
+	s.advance()
+}
+
+// an array element or a key with its value
+func (s *Stream) printElement(depth int) {
+	if s.event.Type != parser.KEY {
+		s.printValue(depth)
+		return
+	}
+
+	s.p.writeString(s.p.keyText(s.event.Token))
+	if s.p.cfg.Compact {
+		s.p.writeString(":")
+	} else {
+		s.p.writeString(": ")
+	}
+	s.advance()
+
+	s.p.writeLeading(s.takeComments(false), depth, false)
+	s.printValue(depth)
+}
@@ -0,0 +1,125 @@
+package printer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lastvoidtemplar/json_formatter/internal/lexer"
+	"github.com/lastvoidtemplar/json_formatter/internal/parser"
+	"github.com/lastvoidtemplar/json_formatter/internal/token"
+)
+
+func formatStream(input string, dialect token.Dialect, cfg Config, t *testing.T) (string, error) {
+	lex := lexer.NewWithDialect(strings.NewReader(input), dialect)
+	p, err := parser.NewWithDialect(lex, dialect)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	var sb strings.Builder
+	s, err := NewStream(&sb, cfg)
+
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	err = s.Print(p.Events())
+	return sb.String(), err
+}
+
+func TestStreamSameAsPrinter(t *testing.T) {
+	inputs := []string{
+		`{"name":"Jason","age":27,"hobbies":["Programming",false,42.69,null],"empty":{},"list":[]}`,
+		`// head
+{
+	// before a
+	"a": 1, // after a
+	"b" /* before colon */: /* before value */ [1, /* inline */ 2 // after 2
+	] // after b
+	/* dangling */
+} // tail`,
+		"[1 /* before comma */, 2\n// own line before comma\n, 3]",
+		"[ // only comment\n]",
+		"{ /* block */ }",
+		`[[[]], {"a": {"b": [1, {}]}}]`,
+		`"root" // comment`,
+		"/* a */ /* b */ 1 /* c */\n// d",
+		`[/*0*/0, /*a*/ /*b*/ 1]`,
+		`{/*c*/"a": [/*only*/], "b": {/* x */}}`,
+	}
+
+	configs := []Config{
+		DefaultConfig(),
+		{Indent: "\t", Newline: "\r\n"},
+		{Newline: "\n", Compact: true},
+		{Indent: "  ", Newline: "\n", StrictJSON: true},
+	}
+
+	for _, input := range inputs {
+		for _, cfg := range configs {
+			expected := format(input, cfg, t)
+			actual, err := formatStream(input, token.JSON, cfg, t)
+
+			if err != nil {
+				t.Fatalf("Failed to stream %s: %v", input, err)
+			}
+			if actual != expected {
+				t.Errorf("Expected:\n%s\nbut got:\n%s", expected, actual)
+			}
+		}
+	}
+}
+
+func TestStreamJSON5(t *testing.T) {
+	input := `// config
+{key: 'it\'s "quoted"', hex: 0xFF, nums: [.5, 5., +1, -Infinity, NaN,], // nums
+}`
+
+	configs := []Config{
+		DefaultConfig(),
+		{Indent: "  ", Newline: "\n", TrailingCommas: true},
+		{Indent: "  ", Newline: "\n", StrictJSON: true},
+	}
+
+	for _, cfg := range configs {
+		expected := formatJSON5(input, cfg, t)
+		actual, err := formatStream(input, token.JSON5, cfg, t)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Errorf("Expected:\n%s\nbut got:\n%s", expected, actual)
+		}
+	}
+}
+
+func TestStreamError(t *testing.T) {
+	actual, err := formatStream(`[1, {"a": 2} 3]`, token.JSON, DefaultConfig(), t)
+
+	if !errors.Is(err, parser.ErrMissingArraySeparator) {
+		t.Fatalf("Expected %v, but got %v", parser.ErrMissingArraySeparator, err)
+	}
+
+	// the output before the error is already written
+	expected := "[\n    1,\n    {\n        \"a\": 2\n    }"
+	if actual != expected {
+		t.Fatalf("Expected %q, but got %q", expected, actual)
+	}
+}
+
+func TestStreamUnsupported(t *testing.T) {
+	configs := []Config{
+		{SortKeys: LEXICOGRAPHIC},
+		{KeyPriority: []string{"id"}},
+		{MaxWidth: 80},
+	}
+
+	for _, cfg := range configs {
+		if _, err := NewStream(&strings.Builder{}, cfg); !errors.Is(err, ErrStreamUnsupported) {
+			t.Errorf("Expected %v for %+v, but got %v", ErrStreamUnsupported, cfg, err)
+		}
+	}
+}
@@ -5,6 +5,8 @@ type TokenType byte
 const (
 	UNDEFINED TokenType = iota
 	ERR
+	// the token is not well-formed utf-8
+	INVALID_UTF8
 
 	EOF
 
@@ -21,6 +23,12 @@ const (
 	FALSE
 	NUMBER_LITERAL
 	STRING_LITERAL
+	// json5 only
+	SINGLE_STRING_LITERAL
+	IDENTIFIER
+
+	LINE_COMMENT
+	BLOCK_COMMENT
 )
 
 type Token struct {
@@ -28,6 +36,28 @@ type Token struct {
 	Literal string
 	Row     int
 	Colm    int
+	// byte offsets of the first byte and the byte after the token
+	Offset    int
+	EndOffset int
+	// position right after the token
+	EndRow  int
+	EndColm int
+}
+
+// place in the input
+type Position struct {
+	// bytes from the start of the input
+	Offset int
+	Row    int
+	Colm   int
+}
+
+func (tok Token) Start() Position {
+	return Position{Offset: tok.Offset, Row: tok.Row, Colm: tok.Colm}
+}
+
+func (tok Token) End() Position {
+	return Position{Offset: tok.EndOffset, Row: tok.EndRow, Colm: tok.EndColm}
 }
 
 func New(typ TokenType, literal string, row int, colm int) Token {
@@ -38,3 +68,22 @@ func New(typ TokenType, literal string, row int, colm int) Token {
 		Colm:    colm,
 	}
 }
+
+type Dialect byte
+
+const (
+	// JSON with comments
+	JSON Dialect = iota
+	// https://spec.json5.org
+	JSON5
+)
+
+// unit of the columns
+type ColumnUnit byte
+
+const (
+	BYTES ColumnUnit = iota
+	CODE_POINTS
+	// the unit of the language server protocol
+	UTF16_CODE_UNITS
+)
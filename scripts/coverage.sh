@@ -7,5 +7,17 @@ go test -coverprofile=tmp.out ./internal/parser
 tail -n +2 tmp.out | grep -v "parser_error" >> coverage.out
 rm tmp.out
 
+go test -coverprofile=tmp.out ./internal/printer
+tail -n +2 tmp.out >> coverage.out
+rm tmp.out
+
+go test -coverprofile=tmp.out ./internal/diff
+tail -n +2 tmp.out >> coverage.out
+rm tmp.out
+
+go test -coverprofile=tmp.out ./internal/diagnostic
+tail -n +2 tmp.out >> coverage.out
+rm tmp.out
+
 go tool cover -html=coverage.out -o ${out%%/}/coverage.html
 rm coverage.out
\ No newline at end of file